@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lewinz/go-gen/util/template"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Command line arguments
+	olderThan time.Duration
+
+	// cacheCmd is the template cache management command
+	cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the template cache",
+		Long: `Manage the cache of Git template repositories.
+The cache directory is $GO_GEN_CACHE, $XDG_CACHE_HOME/go-gen or ~/.go-gen.`,
+	}
+
+	// pathCmd prints the cache directory
+	pathCmd = &cobra.Command{
+		Use:   "path",
+		Short: "Print the cache directory",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root, err := template.CacheRoot()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), root)
+			return nil
+		},
+	}
+
+	// listCmd lists the cached repositories
+	listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List cached template repositories",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := template.ListCache()
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "KEY\tURL\tREF\tCOMMIT\tLAST USED")
+			for _, entry := range entries {
+				ref := entry.Ref
+				if ref == "" {
+					ref = "HEAD"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.Key, entry.URL, ref, shortCommit(entry.Commit), entry.LastUsed.Format(time.DateTime))
+			}
+			return w.Flush()
+		},
+	}
+
+	// updateCmd refreshes cached repositories
+	updateCmd = &cobra.Command{
+		Use:   "update [url...]",
+		Short: "Update cached template repositories",
+		Long:  `Fetch the latest commit of cached template repositories, all of them or those with the given URLs.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			entries, err := template.ListCache()
+			if err != nil {
+				return err
+			}
+			urls := make(map[string]bool)
+			for _, url := range args {
+				urls[url] = true
+			}
+			cached := make(map[string]bool)
+			for _, entry := range entries {
+				cached[entry.URL] = true
+			}
+			for _, url := range args {
+				if !cached[url] {
+					return fmt.Errorf("template %s is not cached", url)
+				}
+			}
+			for _, entry := range entries {
+				if len(urls) > 0 && !urls[entry.URL] {
+					continue
+				}
+				updated, err := template.UpdateCache(entry)
+				if err != nil {
+					return fmt.Errorf("update %s: %w", entry.URL, err)
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", source(updated), shortCommit(updated.Commit))
+			}
+			return nil
+		},
+	}
+
+	// pruneCmd removes unused entries
+	pruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "Remove cached repositories that have not been used recently",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			removed, err := template.PruneCache(olderThan)
+			if err != nil {
+				return err
+			}
+			for _, key := range removed {
+				fmt.Fprintf(cmd.OutOrStdout(), "removed %s\n", key)
+			}
+			return nil
+		},
+	}
+
+	// cleanCmd removes all entries
+	cleanCmd = &cobra.Command{
+		Use:   "clean",
+		Short: "Remove all cached repositories",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			removed, err := template.CleanCache()
+			if err != nil {
+				return err
+			}
+			for _, key := range removed {
+				fmt.Fprintf(cmd.OutOrStdout(), "removed %s\n", key)
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	// Add subcommands
+	cacheCmd.AddCommand(pathCmd, listCmd, updateCmd, pruneCmd, cleanCmd)
+
+	pruneCmd.Flags().DurationVar(&olderThan, "older-than", 30*24*time.Hour, "Remove entries not used for this long")
+}
+
+// source formats the URL and ref of a cache entry as accepted by --template
+func source(entry template.CacheEntry) string {
+	if entry.Ref == "" {
+		return entry.URL
+	}
+	return entry.URL + "@" + entry.Ref
+}
+
+// shortCommit abbreviates a commit hash for display
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
+
+// GetCacheCmd returns the template cache management command
+func GetCacheCmd() *cobra.Command {
+	return cacheCmd
+}
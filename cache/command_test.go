@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// execute runs the cache command with args and returns its output
+func execute(t *testing.T, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := GetCacheCmd()
+	cmd.SetOut(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+// writeEntry creates a cache entry with metadata in root
+func writeEntry(t *testing.T, root, key, content string) {
+	err := os.MkdirAll(filepath.Join(root, key), 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(root, key+".json"), []byte(content), 0644)
+	assert.NoError(t, err)
+}
+
+func TestGetCacheCmd(t *testing.T) {
+	cmd := GetCacheCmd()
+	assert.NotNil(t, cmd)
+	assert.Equal(t, "cache", cmd.Use)
+	assert.Equal(t, "Manage the template cache", cmd.Short)
+
+	// Check if all subcommands exist
+	var names []string
+	for _, subCmd := range cmd.Commands() {
+		names = append(names, subCmd.Name())
+	}
+	assert.ElementsMatch(t, []string{"path", "list", "update", "prune", "clean"}, names)
+	assert.NotNil(t, pruneCmd.Flag("older-than"))
+}
+
+func TestPathCmd(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("GO_GEN_CACHE", root)
+
+	out, err := execute(t, "path")
+	assert.NoError(t, err)
+	assert.Equal(t, root+"\n", out)
+}
+
+func TestListCmd(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("GO_GEN_CACHE", root)
+
+	writeEntry(t, root, "templates-0123456789ab", `{"url": "https://github.com/a/templates", "ref": "v1.4.0", "commit": "abcdef1234567890abcd", "lastUsed": "2024-01-02T03:04:05Z"}`)
+	writeEntry(t, root, "templates-ba9876543210", `{"url": "https://github.com/b/templates", "commit": "1234567", "lastUsed": "2024-01-02T03:04:05Z"}`)
+
+	out, err := execute(t, "list")
+	assert.NoError(t, err)
+	assert.Equal(t, "KEY                     URL                             REF     COMMIT        LAST USED\n"+
+		"templates-0123456789ab  https://github.com/a/templates  v1.4.0  abcdef123456  2024-01-02 03:04:05\n"+
+		"templates-ba9876543210  https://github.com/b/templates  HEAD    1234567       2024-01-02 03:04:05\n", out)
+}
+
+func TestPruneAndCleanCmd(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("GO_GEN_CACHE", root)
+
+	writeEntry(t, root, "templates-0123456789ab", `{"url": "https://github.com/a/templates", "lastUsed": "2000-01-01T00:00:00Z"}`)
+	writeEntry(t, root, "templates-ba9876543210", `{"url": "https://github.com/b/templates", "lastUsed": "2999-01-01T00:00:00Z"}`)
+
+	out, err := execute(t, "prune", "--older-than", "24h")
+	assert.NoError(t, err)
+	assert.Equal(t, "removed templates-0123456789ab\n", out)
+
+	out, err = execute(t, "clean")
+	assert.NoError(t, err)
+	assert.Equal(t, "removed templates-ba9876543210\n", out)
+
+	files, err := os.ReadDir(root)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestUpdateCmdWithoutEntries(t *testing.T) {
+	t.Setenv("GO_GEN_CACHE", t.TempDir())
+
+	out, err := execute(t, "update")
+	assert.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+func TestUpdateCmdNotCached(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("GO_GEN_CACHE", root)
+
+	writeEntry(t, root, "templates-0123456789ab", `{"url": "https://github.com/a/templates", "commit": "abcdef1234567890abcd"}`)
+
+	_, err := execute(t, "update", "https://github.com/b/templates")
+	assert.EqualError(t, err, "template https://github.com/b/templates is not cached")
+}
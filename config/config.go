@@ -0,0 +1,179 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/lewinz/go-gen/util/template"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FileName is the name of the project config file
+	FileName = ".go-gen.yaml"
+
+	// configEnv overrides the location of the project config file
+	configEnv = "GO_GEN_CONFIG"
+)
+
+// Settings are the defaults of a generator's command line flags
+type Settings struct {
+	Dir          string                 `yaml:"dir"`          // Output directory
+	Template     string                 `yaml:"template"`     // Template source
+	TemplateRef  string                 `yaml:"templateRef"`  // Tag, branch or commit of a Git template, or version of a Go module
+	TemplatePath string                 `yaml:"templatePath"` // Template pack directory inside the template
+	FileStyle    string                 `yaml:"fileStyle"`    // File naming style
+	Package      string                 `yaml:"package"`      // Package name of the generated code
+	Module       string                 `yaml:"module"`       // Module path of the project
+	Vars         map[string]interface{} `yaml:"vars"`         // Template variables
+}
+
+// Config is the project config file. The top-level settings apply to every
+// generator, the settings under generators override them for one generator
+type Config struct {
+	Settings   `yaml:",inline"`
+	Generators map[string]Settings `yaml:"generators"`
+
+	// Path of the config file, relative paths in it are resolved against its directory
+	Path string `yaml:"-"`
+}
+
+// stringSettings are the string settings and the GO_GEN_* environment variables
+// that override them
+var stringSettings = []struct {
+	env   string
+	field func(*Settings) *string
+}{
+	{"GO_GEN_DIR", func(s *Settings) *string { return &s.Dir }},
+	{"GO_GEN_TEMPLATE", func(s *Settings) *string { return &s.Template }},
+	{"GO_GEN_TEMPLATE_REF", func(s *Settings) *string { return &s.TemplateRef }},
+	{"GO_GEN_TEMPLATE_PATH", func(s *Settings) *string { return &s.TemplatePath }},
+	{"GO_GEN_FILE_STYLE", func(s *Settings) *string { return &s.FileStyle }},
+	{"GO_GEN_PACKAGE", func(s *Settings) *string { return &s.Package }},
+	{"GO_GEN_MODULE", func(s *Settings) *string { return &s.Module }},
+}
+
+// Find returns the path of the project config file: $GO_GEN_CONFIG if set,
+// otherwise the first .go-gen.yaml in dir or its parents. It returns an empty
+// path if there is none
+func Find(dir string) (string, error) {
+	if path := os.Getenv(configEnv); path != "" {
+		return path, nil
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, FileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads a project config file
+func Load(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	config := &Config{Path: path}
+	if err := yaml.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Generator returns the settings of a generator, with relative paths resolved
+// against the directory of the config file
+func (c *Config) Generator(name string) Settings {
+	var settings Settings
+	settings.merge(c.Settings)
+	if generator, ok := c.Generators[name]; ok {
+		settings.merge(generator)
+	}
+
+	dir := filepath.Dir(c.Path)
+	if settings.Dir != "" && !filepath.IsAbs(settings.Dir) {
+		settings.Dir = filepath.Join(dir, settings.Dir)
+	}
+	if isLocalPath(settings.Template) && !filepath.IsAbs(settings.Template) {
+		settings.Template = filepath.Join(dir, settings.Template)
+	}
+	return settings
+}
+
+// Resolve returns the settings of a generator from the project config found from
+// the working directory, overridden by GO_GEN_* environment variables. When the
+// template is overridden, the ref and path of the configured template are dropped
+func Resolve(generator string) (Settings, error) {
+	var settings Settings
+	wd, err := os.Getwd()
+	if err != nil {
+		return settings, err
+	}
+	path, err := Find(wd)
+	if err != nil {
+		return settings, err
+	}
+	if path != "" {
+		config, err := Load(path)
+		if errors.Is(err, fs.ErrNotExist) && os.Getenv(configEnv) != "" {
+			return settings, fmt.Errorf("config %s set by %s does not exist", path, configEnv)
+		}
+		if err != nil {
+			return settings, err
+		}
+		settings = config.Generator(generator)
+	}
+	env := FromEnv()
+	if env.Template != "" {
+		// The configured ref and path belong to the configured template
+		settings.TemplateRef, settings.TemplatePath = "", ""
+	}
+	settings.merge(env)
+	return settings, nil
+}
+
+// FromEnv returns the settings given by GO_GEN_* environment variables
+func FromEnv() Settings {
+	var settings Settings
+	for _, setting := range stringSettings {
+		*setting.field(&settings) = os.Getenv(setting.env)
+	}
+	return settings
+}
+
+// merge overrides the settings with the non-empty values of other.
+// Variables are merged key by key
+func (s *Settings) merge(other Settings) {
+	for _, setting := range stringSettings {
+		if value := *setting.field(&other); value != "" {
+			*setting.field(s) = value
+		}
+	}
+	if len(other.Vars) > 0 {
+		vars := make(map[string]interface{}, len(s.Vars)+len(other.Vars))
+		for k, v := range s.Vars {
+			vars[k] = v
+		}
+		for k, v := range other.Vars {
+			vars[k] = v
+		}
+		s.Vars = vars
+	}
+}
+
+// isLocalPath checks if a template source is a local directory or archive
+func isLocalPath(source string) bool {
+	return source != "" && source != template.BuiltinTemplate && !template.IsTemplateURL(source)
+}
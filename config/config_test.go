@@ -0,0 +1,164 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `
+dir: ./internal/model
+fileStyle: camel
+module: github.com/org/app
+vars:
+  collection: users
+  soft_delete: true
+generators:
+  mongo:
+    template: ./templates
+    templateRef: v1.2.0
+    package: models
+    vars:
+      collection: accounts
+`
+
+// writeConfig writes a project config file in dir
+func writeConfig(t *testing.T, dir, content string) string {
+	path := filepath.Join(dir, FileName)
+	err := os.WriteFile(path, []byte(content), 0644)
+	assert.NoError(t, err)
+	return path
+}
+
+func TestFind(t *testing.T) {
+	t.Setenv("GO_GEN_CONFIG", "")
+	root := t.TempDir()
+	nested := filepath.Join(root, "internal", "model")
+	err := os.MkdirAll(nested, 0755)
+	assert.NoError(t, err)
+
+	// No config file
+	path, err := Find(nested)
+	assert.NoError(t, err)
+	assert.Empty(t, path)
+
+	// The nearest config file in a parent directory is used
+	expected := writeConfig(t, root, "")
+	path, err = Find(nested)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, path)
+
+	expected = writeConfig(t, filepath.Join(root, "internal"), "")
+	path, err = Find(nested)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, path)
+
+	// GO_GEN_CONFIG takes precedence
+	t.Setenv("GO_GEN_CONFIG", "/etc/go-gen.yaml")
+	path, err = Find(nested)
+	assert.NoError(t, err)
+	assert.Equal(t, "/etc/go-gen.yaml", path)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	config, err := Load(writeConfig(t, dir, testConfig))
+	assert.NoError(t, err)
+	assert.Equal(t, "./internal/model", config.Dir)
+	assert.Equal(t, "camel", config.FileStyle)
+	assert.Equal(t, "v1.2.0", config.Generators["mongo"].TemplateRef)
+
+	_, err = Load(writeConfig(t, dir, "dir: [unclosed"))
+	assert.ErrorContains(t, err, "parse config")
+
+	_, err = Load(filepath.Join(dir, "missing.yaml"))
+	assert.ErrorContains(t, err, "read config")
+}
+
+func TestConfigGenerator(t *testing.T) {
+	dir := t.TempDir()
+	config, err := Load(writeConfig(t, dir, testConfig))
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		generator string
+		expected  Settings
+	}{
+		{
+			name:      "generator settings override defaults",
+			generator: "mongo",
+			expected: Settings{
+				Dir:         filepath.Join(dir, "internal", "model"),
+				Template:    filepath.Join(dir, "templates"),
+				TemplateRef: "v1.2.0",
+				FileStyle:   "camel",
+				Package:     "models",
+				Module:      "github.com/org/app",
+				Vars:        map[string]interface{}{"collection": "accounts", "soft_delete": true},
+			},
+		},
+		{
+			name:      "defaults only",
+			generator: "mysql",
+			expected: Settings{
+				Dir:       filepath.Join(dir, "internal", "model"),
+				FileStyle: "camel",
+				Module:    "github.com/org/app",
+				Vars:      map[string]interface{}{"collection": "users", "soft_delete": true},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, config.Generator(tc.generator))
+		})
+	}
+
+	// Remote and built-in templates are not resolved against the config directory
+	for _, template := range []string{"builtin", "https://github.com/org/templates", "github.com/org/templates@v1.2.0"} {
+		config.Generators["mongo"] = Settings{Template: template}
+		assert.Equal(t, template, config.Generator("mongo").Template)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, testConfig)
+	t.Chdir(dir)
+	t.Setenv("GO_GEN_CONFIG", "")
+	for _, setting := range stringSettings {
+		t.Setenv(setting.env, "")
+	}
+
+	// Config file only
+	settings, err := Resolve("mongo")
+	assert.NoError(t, err)
+	assert.Equal(t, "camel", settings.FileStyle)
+	assert.Equal(t, "v1.2.0", settings.TemplateRef)
+
+	// Environment variables override the config file
+	t.Setenv("GO_GEN_FILE_STYLE", "kebab")
+	t.Setenv("GO_GEN_PACKAGE", "entity")
+	settings, err = Resolve("mongo")
+	assert.NoError(t, err)
+	assert.Equal(t, "kebab", settings.FileStyle)
+	assert.Equal(t, "entity", settings.Package)
+	assert.Equal(t, "github.com/org/app", settings.Module)
+
+	// The configured ref is dropped when the environment selects another template
+	t.Setenv("GO_GEN_TEMPLATE", "./other")
+	settings, err = Resolve("mongo")
+	assert.NoError(t, err)
+	assert.Equal(t, "./other", settings.Template)
+	assert.Empty(t, settings.TemplateRef)
+
+	// Missing config file set by GO_GEN_CONFIG
+	t.Setenv("GO_GEN_CONFIG", filepath.Join(dir, "missing.yaml"))
+	_, err = Resolve("mongo")
+	assert.ErrorContains(t, err, "set by GO_GEN_CONFIG does not exist")
+}
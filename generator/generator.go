@@ -1,6 +1,13 @@
 package generator
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+
+	"github.com/lewinz/go-gen/util/field"
+	"github.com/lewinz/go-gen/util/naming"
+	"github.com/lewinz/go-gen/util/template"
+)
 
 // Generator defines the interface for code generators
 type Generator interface {
@@ -12,10 +19,21 @@ type Generator interface {
 
 // BaseGenerator provides the basic implementation of a generator
 type BaseGenerator struct {
-	Type        string // Model type
-	OutputDir   string // Output directory
-	TemplateDir string // Template directory
-	FileStyle   string // File naming style
+	Type             string                 // Model type
+	OutputDir        string                 // Output directory
+	TemplateDir      string                 // Template directory
+	TemplateRef      string                 // Tag, branch or commit of a Git template, or version of a Go module
+	TemplatePath     string                 // Template pack directory inside the template directory or repository
+	TemplateChecksum string                 // Expected checksum of an archive (sha256:<hex>) or Go module (h1:<hash>) template
+	FileStyle        string                 // File naming style
+	Package          string                 // Package name of the generated code, empty to use the output directory name
+	Module           string                 // Module path of the project
+	Vars             map[string]interface{} // Template variables
+	Fields           []field.Field          // Fields of the model
+	Offline          bool                   // Use cached Git templates and the Go module cache without contacting the remote
+	DryRun           bool                   // List the files that would change without writing them
+	Diff             bool                   // Print a unified diff against existing files without writing them
+	OnConflict       string                 // Policy for existing output files, empty to use the template setting
 }
 
 // NewBaseGenerator creates a new base generator
@@ -41,3 +59,51 @@ func (g *BaseGenerator) Validate() error {
 	}
 	return nil
 }
+
+// NewEngine creates a template engine for the named generator with the options of the generator
+func (g *BaseGenerator) NewEngine(name string) *template.Engine {
+	return template.NewEngine(naming.Style(g.FileStyle),
+		template.WithGenerator(name),
+		template.WithRef(g.TemplateRef),
+		template.WithPath(g.TemplatePath),
+		template.WithChecksum(g.TemplateChecksum),
+		template.WithPackage(g.Package),
+		template.WithModule(g.Module),
+		template.WithVars(g.Vars),
+		template.WithFields(g.Fields),
+		template.WithOffline(g.Offline),
+		template.WithDryRun(g.DryRun),
+		template.WithDiff(g.Diff),
+		template.WithConflictPolicy(template.ConflictPolicy(g.OnConflict)),
+	)
+}
+
+// ValidStyle checks if the file naming style is valid
+func (g *BaseGenerator) ValidStyle() bool {
+	switch g.FileStyle {
+	case "snake", "camel", "pascal", "kebab":
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidTemplatePath checks if the template directory is valid
+func (g *BaseGenerator) ValidTemplatePath() bool {
+	// Check if it's the embedded templates
+	if g.TemplateDir == template.BuiltinTemplate {
+		return true
+	}
+
+	// Check if it's a git repository or archive URL
+	if template.IsTemplateURL(g.TemplateDir) {
+		return true
+	}
+
+	// Check if it's a local path
+	if _, err := os.Stat(g.TemplateDir); err == nil {
+		return true
+	}
+
+	return false
+}
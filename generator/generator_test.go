@@ -3,6 +3,7 @@ package generator
 import (
 	"testing"
 
+	"github.com/lewinz/go-gen/util/template"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -114,3 +115,32 @@ func TestBaseGeneratorValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestBaseGeneratorValidStyle(t *testing.T) {
+	for _, style := range []string{"snake", "camel", "pascal", "kebab"} {
+		assert.True(t, NewBaseGenerator("User", "./output", template.BuiltinTemplate, style).ValidStyle(), style)
+	}
+	for _, style := range []string{"", "upper", "Snake"} {
+		assert.False(t, NewBaseGenerator("User", "./output", template.BuiltinTemplate, style).ValidStyle(), style)
+	}
+}
+
+func TestBaseGeneratorValidTemplatePath(t *testing.T) {
+	testCases := []struct {
+		name        string
+		templateDir string
+		expected    bool
+	}{
+		{"builtin", template.BuiltinTemplate, true},
+		{"git repository", "https://github.com/user/templates.git", true},
+		{"local directory", t.TempDir(), true},
+		{"missing directory", "./does-not-exist", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			generator := NewBaseGenerator("User", "./output", tc.templateDir, "snake")
+			assert.Equal(t, tc.expected, generator.ValidTemplatePath())
+		})
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/lewinz/go-gen/cache"
 	"github.com/lewinz/go-gen/model"
+	"github.com/lewinz/go-gen/util/template"
 	"github.com/spf13/cobra"
 )
 
@@ -30,8 +32,12 @@ var versionCmd = &cobra.Command{
 }
 
 func init() {
+	// Let template packs check their minimum go-gen version
+	template.Version = version
+
 	// Add subcommands
 	rootCmd.AddCommand(model.GetModelCmd())
+	rootCmd.AddCommand(cache.GetCacheCmd())
 	rootCmd.AddCommand(versionCmd)
 }
 
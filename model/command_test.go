@@ -1,9 +1,14 @@
 package model
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -12,7 +17,7 @@ func TestGetModelCmd(t *testing.T) {
 	assert.NotNil(t, cmd)
 	assert.Equal(t, "model", cmd.Use)
 	assert.Equal(t, "Generate model code", cmd.Short)
-	assert.Equal(t, "Generate model code for different databases like MongoDB, MySQL, etc.", cmd.Long)
+	assert.Equal(t, "Generate model code for MongoDB, MySQL and PostgreSQL.", cmd.Long)
 }
 
 func TestModelCmdFlags(t *testing.T) {
@@ -22,7 +27,21 @@ func TestModelCmdFlags(t *testing.T) {
 	assert.NotNil(t, cmd.Flag("type"))
 	assert.NotNil(t, cmd.Flag("dir"))
 	assert.NotNil(t, cmd.Flag("template"))
+	assert.NotNil(t, cmd.Flag("template-ref"))
+	assert.NotNil(t, cmd.Flag("template-path"))
 	assert.NotNil(t, cmd.Flag("file-style"))
+	assert.NotNil(t, cmd.Flag("package"))
+	assert.NotNil(t, cmd.Flag("module"))
+	assert.NotNil(t, cmd.Flag("set"))
+	assert.NotNil(t, cmd.Flag("values"))
+	assert.NotNil(t, cmd.Flag("field"))
+	assert.NotNil(t, cmd.Flag("schema"))
+	assert.NotNil(t, cmd.Flag("from-json"))
+	assert.NotNil(t, cmd.Flag("from-jsonschema"))
+	assert.NotNil(t, cmd.Flag("offline"))
+	assert.NotNil(t, cmd.Flag("dry-run"))
+	assert.NotNil(t, cmd.Flag("diff"))
+	assert.NotNil(t, cmd.Flag("on-conflict"))
 
 	// Check if flags are required by trying to execute mongo subcommand without required flags
 	cmd.SetArgs([]string{"mongo"})
@@ -83,3 +102,467 @@ func TestCommandExecution(t *testing.T) {
 	// actual file system operations and template processing.
 	// This would be better tested with integration tests.
 }
+
+func TestMongoWithConfig(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, ".go-gen.yaml"), []byte(`
+fileStyle: kebab
+generators:
+  mongo:
+    dir: ./internal/entity
+    package: entity
+`), 0644)
+	assert.NoError(t, err)
+	err = os.MkdirAll(filepath.Join(dir, "cmd", "app"), 0755)
+	assert.NoError(t, err)
+	t.Chdir(filepath.Join(dir, "cmd", "app"))
+	t.Setenv("GO_GEN_CONFIG", "")
+	t.Setenv("GO_GEN_DIR", "")
+	t.Setenv("GO_GEN_FILE_STYLE", "")
+	t.Setenv("GO_GEN_PACKAGE", "")
+
+	cmd := GetModelCmd()
+	resetFlags(t, cmd)
+
+	// The config file is found from a subdirectory and its paths are relative to it
+	cmd.SetArgs([]string{"mongo", "--type", "user"})
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(dir, "internal", "entity", "user-model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "package entity")
+
+	// Environment variables override the config file
+	t.Setenv("GO_GEN_FILE_STYLE", "snake")
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "internal", "entity", "user_model.go"))
+	assert.NoError(t, err)
+
+	// Flags override both
+	cmd.SetArgs([]string{"mongo", "--type", "user", "--dir", "out", "--file-style", "camel"})
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "cmd", "app", "out", "userModel.go"))
+	assert.NoError(t, err)
+}
+
+func TestMongoWithVars(t *testing.T) {
+	dir := t.TempDir()
+	templateDir := filepath.Join(dir, "templates")
+	err := os.MkdirAll(templateDir, 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(templateDir, "model.tpl"), []byte(`package {{.PackageName}}
+
+// Collection: {{.Vars.collection}}, database: {{.Vars.db.name}}, owner: {{.Vars.team}}
+{{- if .Vars.soft_delete}}
+// Soft delete enabled
+{{- end}}
+`), 0644)
+	assert.NoError(t, err)
+	valuesFile := filepath.Join(dir, "values.yaml")
+	err = os.WriteFile(valuesFile, []byte("collection: users\nsoft_delete: false\ndb:\n  name: app\n"), 0644)
+	assert.NoError(t, err)
+	t.Chdir(dir)
+	t.Setenv("GO_GEN_CONFIG", filepath.Join(dir, "none.yaml"))
+	err = os.WriteFile(filepath.Join(dir, "none.yaml"), []byte("vars:\n  team: platform\n  collection: accounts\n"), 0644)
+	assert.NoError(t, err)
+
+	cmd := GetModelCmd()
+	resetFlags(t, cmd)
+
+	// --values overrides the config file, --set overrides both
+	cmd.SetArgs([]string{"mongo", "--type", "user", "--dir", "model", "--template", templateDir,
+		"--values", valuesFile, "--set", "soft_delete=true", "--set", "team=core"})
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(dir, "model", "user_model.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package model\n\n// Collection: users, database: app, owner: core\n// Soft delete enabled\n", string(content))
+
+	cmd.SetArgs([]string{"mongo", "--type", "user", "--dir", "model", "--template", templateDir, "--set", "collection"})
+	err = cmd.Execute()
+	assert.ErrorContains(t, err, "expected key=value")
+}
+
+func TestMongoWithFields(t *testing.T) {
+	dir := t.TempDir()
+	schemaFile := filepath.Join(dir, "user.yaml")
+	err := os.WriteFile(schemaFile, []byte("fields:\n  - name: email\n    type: string\n    unique: true\n"), 0644)
+	assert.NoError(t, err)
+	t.Chdir(dir)
+	t.Setenv("GO_GEN_CONFIG", "")
+
+	cmd := GetModelCmd()
+	resetFlags(t, cmd)
+
+	// Fields from the schema come before the --field ones
+	cmd.SetArgs([]string{"mongo", "--type", "user", "--dir", "model", "--schema", schemaFile,
+		"--field", "name:string", "--field", "tags:[]string:optional"})
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(dir, "model", "user_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "\t\tEmail string   `bson:\"email\" json:\"email\"`\n\t\tName  string   `bson:\"name\" json:\"name\"`\n")
+	assert.Contains(t, string(content), "FindByEmail(ctx context.Context, value string) (*User, error)")
+
+	// Conditions of pointer fields keep their type
+	cmd.SetArgs([]string{"mongo", "--type", "user", "--dir", "pointer", "--schema", schemaFile,
+		"--field", "nickname:*string:index"})
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(dir, "pointer", "user_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "\t\tEmail    *string\n\t\tNickname *string\n\t}")
+	assert.Contains(t, string(content), "filter[\"nickname\"] = *c.Nickname")
+
+	testCases := []struct {
+		name        string
+		field       string
+		expectError string
+	}{
+		{"invalid spec", "name", "expected name:type[:flags]"},
+		{"defined twice", "email:string", "field Email is defined twice"},
+		{"reserved", "created_time:time.Time", "field CreatedTime is generated by the template"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Repeated flags append to the values of the previous run
+			clearFlag(t, cmd, "field")
+			cmd.SetArgs([]string{"mongo", "--type", "user", "--dir", "model", "--schema", schemaFile, "--field", tc.field})
+			err := cmd.Execute()
+			assert.ErrorContains(t, err, tc.expectError)
+		})
+	}
+
+	// Custom templates may declare any field
+	templateDir := filepath.Join(dir, "templates")
+	err = os.MkdirAll(templateDir, 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(templateDir, "model.tpl"), []byte("package {{.PackageName}}\n{{range .Fields}}\n// {{.NamePascal}} {{.Type}}{{end}}\n"), 0644)
+	assert.NoError(t, err)
+	clearFlag(t, cmd, "field")
+	cmd.SetArgs([]string{"mongo", "--type", "user", "--dir", "custom", "--template", templateDir, "--schema", schemaFile,
+		"--field", "created_time:time.Time"})
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(dir, "custom", "user_model.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package custom\n\n// Email string\n// CreatedTime time.Time\n", string(content))
+}
+
+func TestMongoFromJSON(t *testing.T) {
+	dir := t.TempDir()
+	sampleFile := filepath.Join(dir, "user.json")
+	err := os.WriteFile(sampleFile, []byte(`{"_id": {"$oid": "5f1d7a1b2c3d4e5f6a7b8c9d"}, "name": "Ann", "address": {"city": "Paris"}, "createdTime": {"$date": "2020-07-26T10:00:00Z"}}
+{"_id": {"$oid": "5f1d7a1b2c3d4e5f6a7b8c9e"}, "name": "Bob", "address": {"city": "Oslo"}, "age": 30}
+`), 0644)
+	assert.NoError(t, err)
+	schemaFile := filepath.Join(dir, "user.schema.json")
+	err = os.WriteFile(schemaFile, []byte(`{"$jsonSchema": {"bsonType": "object", "required": ["name"], "properties": {"name": {"bsonType": "string"}}}}`), 0644)
+	assert.NoError(t, err)
+	t.Chdir(dir)
+	t.Setenv("GO_GEN_CONFIG", "")
+
+	cmd := GetModelCmd()
+	resetFlags(t, cmd)
+
+	// The _id and createdTime of the samples are declared by the template, Id keeps the _id type
+	cmd.SetArgs([]string{"mongo", "--type", "user", "--dir", "model", "--from-json", sampleFile, "--field", "email:string:unique"})
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(dir, "model", "user_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "\t\tId      primitive.ObjectID `bson:\"_id,omitempty\" json:\"id,omitempty\"`\n"+
+		"\t\tName    string             `bson:\"name\" json:\"name\"`\n"+
+		"\t\tAddress UserAddress        `bson:\"address\" json:\"address\"`\n"+
+		"\t\tAge     int                `bson:\"age,omitempty\" json:\"age,omitempty\"`\n"+
+		"\t\tEmail   string             `bson:\"email\" json:\"email\"`\n")
+	assert.Contains(t, string(content), "\t\tFindById(ctx context.Context, id primitive.ObjectID) (*User, error)\n")
+	assert.Contains(t, string(content), "\t\tId    primitive.ObjectID\n\t\tIds   []primitive.ObjectID\n")
+	assert.Contains(t, string(content), "\tif user.Id.IsZero() {\n\t\tuser.Id = primitive.NewObjectID()\n\t}\n")
+	assert.Contains(t, string(content), "\tUserAddress struct {\n\t\tCity string `bson:\"city\" json:\"city\"`\n\t}\n")
+	assert.Equal(t, 1, strings.Count(string(content), "CreatedTime time.Time"))
+
+	clearFlag(t, cmd, "from-json")
+	clearFlag(t, cmd, "field")
+	cmd.SetArgs([]string{"mongo", "--type", "user", "--dir", "model", "--from-jsonschema", schemaFile})
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(dir, "model", "user_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "\t\tId   string `bson:\"_id,omitempty\" json:\"id,omitempty\"`\n\t\tName string `bson:\"name\" json:\"name\"`\n")
+	assert.NotContains(t, string(content), "Address")
+
+	cmd.SetArgs([]string{"mongo", "--type", "user", "--dir", "model", "--from-json", sampleFile, "--from-jsonschema", schemaFile})
+	err = cmd.Execute()
+	assert.ErrorContains(t, err, "none of the others can be")
+
+	clearFlag(t, cmd, "from-json")
+	cmd.SetArgs([]string{"mongo", "--type", "user", "--dir", "model", "--from-jsonschema", sampleFile})
+	err = cmd.Execute()
+	assert.ErrorContains(t, err, "schema has no properties")
+}
+
+func TestMongoWithURI(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("GO_GEN_CONFIG", "")
+	t.Setenv("PATH", dir)
+
+	cmd := GetModelCmd()
+	resetFlags(t, cmd)
+	for _, name := range []string{"uri", "collection", "sample"} {
+		assert.NotNil(t, mongoCmd.Flag(name))
+	}
+	t.Cleanup(func() {
+		for _, name := range []string{"uri", "collection", "sample"} {
+			resetFlag(t, mongoCmd.Flag(name))
+		}
+	})
+
+	// --collection names the collection of the generated model
+	cmd.SetArgs([]string{"mongo", "--type", "user", "--dir", "model", "--collection", "accounts"})
+	err := cmd.Execute()
+	assert.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(dir, "model", "user_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `db.Collection("accounts")`)
+
+	// Introspection runs mongosh, which is not on the PATH
+	cmd.SetArgs([]string{"mongo", "--type", "user", "--dir", "model", "--uri", "mongodb://localhost:27017/app"})
+	err = cmd.Execute()
+	assert.ErrorContains(t, err, "mongosh")
+
+	cmd.SetArgs([]string{"mongo", "--type", "user", "--dir", "model", "--uri", "mongodb://localhost:27017/app", "--from-json", "user.json"})
+	err = cmd.Execute()
+	assert.ErrorContains(t, err, "none of the others can be")
+}
+
+func TestSQLFromDDL(t *testing.T) {
+	dir := t.TempDir()
+	ddlFile := filepath.Join(dir, "schema.sql")
+	err := os.WriteFile(ddlFile, []byte(`
+CREATE TABLE users (
+  id bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  email varchar(255) NOT NULL UNIQUE,
+  nickname varchar(64) DEFAULT NULL
+);
+CREATE TABLE user_logins (
+  id bigserial PRIMARY KEY,
+  user_id bigint NOT NULL,
+  at timestamp with time zone NOT NULL
+);
+CREATE INDEX user_logins_user_id ON user_logins (user_id);
+CREATE TABLE events (
+  id bigint NOT NULL,
+  name varchar(64) NOT NULL
+);
+`), 0644)
+	assert.NoError(t, err)
+	t.Chdir(dir)
+	t.Setenv("GO_GEN_CONFIG", "")
+
+	cmd := GetModelCmd()
+	resetFlags(t, cmd)
+	for _, sub := range []*cobra.Command{mysqlCmd, postgresCmd} {
+		assert.NotNil(t, sub.Flag("ddl"))
+		assert.NotNil(t, sub.Flag("table"))
+	}
+	t.Cleanup(func() {
+		resetFlag(t, mysqlCmd.Flag("ddl"))
+		resetFlag(t, mysqlCmd.Flag("table"))
+		resetFlag(t, postgresCmd.Flag("ddl"))
+		resetFlag(t, postgresCmd.Flag("table"))
+	})
+
+	// The table named after the type in plural, --field adds columns to it
+	cmd.SetArgs([]string{"mysql", "--type", "user", "--dir", "model", "--ddl", ddlFile, "--field", "bio:string"})
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(dir, "model", "user_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "\t\tId       int64   `db:\"id\" json:\"id\"`\n"+
+		"\t\tEmail    string  `db:\"email\" json:\"email\"`\n"+
+		"\t\tNickname *string `db:\"nickname\" json:\"nickname,omitempty\"`\n"+
+		"\t\tBio      string  `db:\"bio\" json:\"bio\"`\n")
+	assert.Contains(t, string(content), "\"INSERT INTO `users` (`email`, `nickname`, `bio`) VALUES (?, ?, ?)\"")
+	assert.Contains(t, string(content), "FindByEmail(ctx context.Context, value string) (*User, error)")
+
+	// --table picks a table for another type name
+	clearFlag(t, cmd, "field")
+	resetFlag(t, mysqlCmd.Flag("ddl"))
+	cmd.SetArgs([]string{"postgres", "--type", "login", "--dir", "model", "--ddl", ddlFile, "--table", "user_logins"})
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(dir, "model", "login_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "`INSERT INTO \"user_logins\" (\"user_id\", \"at\") VALUES ($1, $2) RETURNING \"id\"`")
+	assert.Contains(t, string(content), "\t\tUserId *int64\n")
+
+	// Without --type, one model per table named after the singular table name
+	clearFlag(t, cmd, "type")
+	resetFlag(t, postgresCmd.Flag("ddl"))
+	resetFlag(t, postgresCmd.Flag("table"))
+	cmd.SetArgs([]string{"postgres", "--dir", "all", "--ddl", ddlFile})
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(dir, "all", "user_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "`DELETE FROM \"users\" WHERE \"id\" = $1`")
+	content, err = os.ReadFile(filepath.Join(dir, "all", "user_login_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "func NewUserLoginModel(db *sql.DB) UserLoginModel {")
+
+	// Tables without a primary key keep their columns and have no methods using one
+	content, err = os.ReadFile(filepath.Join(dir, "all", "event_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "`INSERT INTO \"events\" (\"id\", \"name\") VALUES ($1, $2)`")
+	assert.NotContains(t, string(content), "FindById")
+
+	// --table globs select the tables
+	resetFlag(t, postgresCmd.Flag("ddl"))
+	cmd.SetArgs([]string{"mysql", "--dir", "glob", "--ddl", ddlFile, "--table", "user_*"})
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "glob", "user_login_model.go"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "glob", "user_model.go"))
+	assert.True(t, os.IsNotExist(err))
+	resetFlag(t, mysqlCmd.Flag("ddl"))
+	resetFlag(t, mysqlCmd.Flag("table"))
+
+	testCases := []struct {
+		name        string
+		args        []string
+		expectError string
+	}{
+		{"no matching table", []string{"mysql", "--type", "account", "--ddl", ddlFile}, "no table matches type account, use --table to choose one of users, user_logins, events"},
+		{"missing table", []string{"mysql", "--type", "account", "--ddl", ddlFile, "--table", "accounts"}, "no table matches accounts, tables are users, user_logins, events"},
+		{"invalid pattern", []string{"mysql", "--ddl", ddlFile, "--table", "[users"}, `invalid table pattern "[users"`},
+		{"type of several tables", []string{"mysql", "--type", "user", "--ddl", ddlFile, "--table", "user*"}, "--type names a single model but 2 tables match"},
+		{"several tables without ddl", []string{"mysql", "--type", "user", "--field", "id:int64", "--table", "a", "--table", "b"}, "several --table patterns need --ddl or --dsn"},
+		{"missing file", []string{"mysql", "--type", "user", "--ddl", filepath.Join(dir, "missing.sql")}, "read "},
+		{"missing type", []string{"mysql", "--field", "id:int64"}, "type is required"},
+		{"with json", []string{"postgres", "--type", "user", "--ddl", ddlFile, "--from-json", "user.json"}, "none of the others can be"},
+		{"with dsn", []string{"postgres", "--type", "user", "--ddl", ddlFile, "--dsn", "postgres://localhost/app"}, "none of the others can be"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd.SetArgs(append(tc.args, "--dir", "model"))
+			err := cmd.Execute()
+			assert.ErrorContains(t, err, tc.expectError)
+			for _, sub := range []*cobra.Command{mysqlCmd, postgresCmd} {
+				for _, name := range []string{"ddl", "dsn", "table"} {
+					resetFlag(t, sub.Flag(name))
+				}
+			}
+			for _, name := range []string{"type", "field", "from-json"} {
+				clearFlag(t, cmd, name)
+			}
+		})
+	}
+}
+
+func TestSQLUnsupportedTables(t *testing.T) {
+	dir := t.TempDir()
+	ddlFile := filepath.Join(dir, "schema.sql")
+	err := os.WriteFile(ddlFile, []byte(`
+CREATE TABLE accounts (id bigserial PRIMARY KEY, email text NOT NULL);
+CREATE TABLE groups (id bigserial PRIMARY KEY, name text NOT NULL);
+CREATE TABLE group_members (
+  group_id bigint NOT NULL,
+  account_id bigint NOT NULL,
+  PRIMARY KEY (group_id, account_id)
+);
+`), 0644)
+	assert.NoError(t, err)
+	t.Chdir(dir)
+	t.Setenv("GO_GEN_CONFIG", "")
+
+	cmd := GetModelCmd()
+	resetFlags(t, cmd)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	t.Cleanup(func() {
+		cmd.SetOut(nil)
+		resetFlag(t, postgresCmd.Flag("ddl"))
+		resetFlag(t, postgresCmd.Flag("table"))
+	})
+
+	// Invalid tables fail the run before any model is written
+	cmd.SetArgs([]string{"postgres", "--dir", "invalid", "--ddl", ddlFile, "--field", "name:string"})
+	err = cmd.Execute()
+	assert.ErrorContains(t, err, "table groups: field Name is defined twice")
+	_, err = os.Stat(filepath.Join(dir, "invalid"))
+	assert.True(t, os.IsNotExist(err))
+	clearFlag(t, cmd, "field")
+	resetFlag(t, postgresCmd.Flag("ddl"))
+
+	// Unsupported tables are skipped with a warning when there are several
+	out.Reset()
+	cmd.SetArgs([]string{"postgres", "--dir", "all", "--ddl", ddlFile})
+	err = cmd.Execute()
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "warning: skipping table group_members: composite primary key (group_id, account_id) is not supported\n")
+	for _, name := range []string{"account_model.go", "group_model.go"} {
+		_, err = os.Stat(filepath.Join(dir, "all", name))
+		assert.NoError(t, err)
+	}
+	_, err = os.Stat(filepath.Join(dir, "all", "group_member_model.go"))
+	assert.True(t, os.IsNotExist(err))
+	resetFlag(t, postgresCmd.Flag("ddl"))
+
+	// A single unsupported table is an error
+	cmd.SetArgs([]string{"postgres", "--dir", "one", "--ddl", ddlFile, "--table", "group_members"})
+	err = cmd.Execute()
+	assert.ErrorContains(t, err, "table group_members: composite primary key (group_id, account_id) is not supported")
+}
+
+func TestSQLWithDSN(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("GO_GEN_CONFIG", "")
+	t.Setenv("PATH", dir)
+
+	cmd := GetModelCmd()
+	resetFlags(t, cmd)
+	t.Cleanup(func() {
+		resetFlag(t, mysqlCmd.Flag("dsn"))
+		resetFlag(t, postgresCmd.Flag("dsn"))
+	})
+
+	// Introspection runs the database client, which is not on the PATH
+	cmd.SetArgs([]string{"postgres", "--dir", "model", "--dsn", "postgres://localhost/app"})
+	err := cmd.Execute()
+	assert.ErrorContains(t, err, "psql")
+
+	cmd.SetArgs([]string{"mysql", "--dir", "model", "--dsn", "root@tcp(localhost:3306)/app"})
+	err = cmd.Execute()
+	assert.ErrorContains(t, err, "mysql")
+}
+
+// clearFlag unsets a flag of a previous run of the command
+func clearFlag(t *testing.T, cmd *cobra.Command, name string) {
+	resetFlag(t, cmd.Flag(name))
+}
+
+// resetFlags restores the default values of the model command flags when the test ends,
+// the flags are package globals shared by all tests
+func resetFlags(t *testing.T, cmd *cobra.Command) {
+	t.Cleanup(func() {
+		cmd.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+			resetFlag(t, flag)
+		})
+	})
+}
+
+// resetFlag restores the default value of a flag
+func resetFlag(t *testing.T, flag *pflag.Flag) {
+	if slice, ok := flag.Value.(pflag.SliceValue); ok {
+		assert.NoError(t, slice.Replace(nil))
+	} else {
+		assert.NoError(t, flag.Value.Set(flag.DefValue))
+	}
+	flag.Changed = false
+}
@@ -6,7 +6,7 @@ import (
 	"strings"
 
 	"github.com/lewinz/go-gen/generator"
-	"github.com/lewinz/go-gen/util/naming"
+	"github.com/lewinz/go-gen/util/field"
 	"github.com/lewinz/go-gen/util/template"
 )
 
@@ -22,9 +22,10 @@ func NewMongoGenerator(base *generator.BaseGenerator) *MongoGenerator {
 	if base.FileStyle == "" {
 		base.FileStyle = "snake"
 	}
+	engine := base.NewEngine("mongo")
 	return &MongoGenerator{
 		BaseGenerator: base,
-		engine:        template.NewEngine(naming.Style(base.FileStyle)),
+		engine:        engine,
 	}
 }
 
@@ -34,9 +35,11 @@ func (g *MongoGenerator) Generate() error {
 		return err
 	}
 
-	// Ensure output directory exists
-	if err := os.MkdirAll(g.OutputDir, 0755); err != nil {
-		return fmt.Errorf("create output directory: %w", err)
+	// Ensure output directory exists, previews leave the disk untouched
+	if !g.DryRun && !g.Diff {
+		if err := os.MkdirAll(g.OutputDir, 0755); err != nil {
+			return fmt.Errorf("create output directory: %w", err)
+		}
 	}
 
 	// Generate code using template engine
@@ -50,39 +53,64 @@ func (g *MongoGenerator) Validate() error {
 	}
 
 	// Validate naming styles
-	if !isValidStyle(g.FileStyle) {
+	if !g.ValidStyle() {
 		return fmt.Errorf("invalid file style: %s", g.FileStyle)
 	}
 
+	// Validate conflict policy
+	if _, err := template.ParseConflictPolicy(g.OnConflict); err != nil {
+		return err
+	}
+
+	// Validate fields, the built-in template already declares some of them
+	if err := field.Validate(g.Fields); err != nil {
+		return err
+	}
+	if g.TemplateDir == template.BuiltinTemplate {
+		for _, f := range g.Fields {
+			if isReservedField(f.NamePascal()) {
+				return fmt.Errorf("field %s is generated by the template", f.NamePascal())
+			}
+		}
+	}
+
 	// Validate template directory
-	if !isValidTemplatePath(g.TemplateDir) {
+	if !g.ValidTemplatePath() {
 		return fmt.Errorf("invalid template path: %s", g.TemplateDir)
 	}
 
 	return nil
 }
 
-// isValidStyle checks if the naming style is valid
-func isValidStyle(style string) bool {
-	switch style {
-	case "snake", "camel", "pascal", "kebab":
+// isReservedField checks if a field name is used by the fields of the built-in template
+func isReservedField(name string) bool {
+	switch name {
+	case "Id", "CreatedTime", "UpdatedTime":
 		return true
 	default:
 		return false
 	}
 }
 
-// isValidTemplatePath checks if the template path is valid
-func isValidTemplatePath(path string) bool {
-	// Check if it's a git repository URL
-	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "git@") {
-		return true
+// WithoutReservedFields drops the fields the built-in template declares itself,
+// such as the _id and createdTime of sample documents
+func WithoutReservedFields(fields []field.Field) []field.Field {
+	var result []field.Field
+	for _, f := range fields {
+		if !isReservedField(f.NamePascal()) {
+			result = append(result, f)
+		}
 	}
+	return result
+}
 
-	// Check if it's a local path
-	if _, err := os.Stat(path); err == nil {
-		return true
+// IdType returns the type of the _id field of inferred fields, empty if there is none.
+// The built-in template uses it as the type of Id, so filters match the stored keys
+func IdType(fields []field.Field) string {
+	for _, f := range fields {
+		if f.NamePascal() == "Id" {
+			return strings.TrimPrefix(f.Type, "*")
+		}
 	}
-
-	return false
+	return ""
 }
@@ -0,0 +1,138 @@
+package mongo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/lewinz/go-gen/util/field"
+	"github.com/lewinz/go-gen/util/template"
+)
+
+// commander runs mongosh, tests replace it
+var commander template.Commander = &template.RealCommander{}
+
+// introspectScript prints sampled documents and the indexes of a collection as relaxed
+// extended JSON. The collection name and sample size are passed as JSON literals
+const introspectScript = `const coll = db.getCollection(%s);
+print(EJSON.stringify({
+  documents: coll.aggregate([{ $sample: { size: %d } }]).toArray(),
+  indexes: coll.getIndexes()
+}, { relaxed: true }));`
+
+// connectScript connects to a URI given as JSON literal, so credentials stay off the
+// command line
+const connectScript = "db = connect(%s);\n"
+
+// collectionInfo is the output of introspectScript
+type collectionInfo struct {
+	Documents json.RawMessage `json:"documents"`
+	Indexes   []struct {
+		Key    map[string]interface{} `json:"key"`
+		Unique bool                   `json:"unique"`
+	} `json:"indexes"`
+}
+
+// Introspect infers the fields of a model from a collection of a running MongoDB.
+// It samples up to sampleSize documents with mongosh, merges their field types and
+// marks the fields of single-field indexes as indexed or unique
+func Introspect(uri, collection string, sampleSize int, typeName string) ([]field.Field, error) {
+	if sampleSize <= 0 {
+		return nil, fmt.Errorf("invalid sample size %d", sampleSize)
+	}
+	name, err := json.Marshal(collection)
+	if err != nil {
+		return nil, err
+	}
+	script := fmt.Sprintf(introspectScript, name, sampleSize)
+
+	// A URI with a password is connected to by a script file only the user can read,
+	// which keeps the password out of the process list
+	var cmd *exec.Cmd
+	if u, err := url.Parse(uri); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			file, err := writeScript(uri, script)
+			if err != nil {
+				return nil, err
+			}
+			defer os.Remove(file)
+			cmd = commander.Command("mongosh", "--nodb", "--quiet", "--norc", file)
+		}
+	}
+	if cmd == nil {
+		cmd = commander.Command("mongosh", uri, "--quiet", "--norc", "--eval", script)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("mongosh: %w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("mongosh: %w", err)
+	}
+
+	var info collectionInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("parse mongosh output: %w", err)
+	}
+	if strings.TrimSpace(string(info.Documents)) == "[]" {
+		return nil, fmt.Errorf("collection %s has no documents", collection)
+	}
+	fields, err := field.InferJSON(info.Documents, typeName)
+	if err != nil {
+		return nil, fmt.Errorf("collection %s: %w", collection, err)
+	}
+
+	for _, index := range info.Indexes {
+		if len(index.Key) != 1 {
+			continue
+		}
+		for key := range index.Key {
+			markIndexed(fields, strings.Split(key, "."), index.Unique)
+		}
+	}
+	return fields, nil
+}
+
+// writeScript writes a mongosh script connecting to uri and running script to a
+// temporary file with mode 0600, and returns its path
+func writeScript(uri, script string) (string, error) {
+	literal, err := json.Marshal(uri)
+	if err != nil {
+		return "", err
+	}
+	file, err := os.CreateTemp("", "go-gen-mongosh-*.js")
+	if err != nil {
+		return "", fmt.Errorf("create mongosh script: %w", err)
+	}
+	_, err = fmt.Fprintf(file, connectScript+"%s\n", literal, script)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("write mongosh script: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// markIndexed marks the field at a dotted index key such as address.city as indexed
+func markIndexed(fields []field.Field, path []string, unique bool) {
+	for i := range fields {
+		if fields[i].TagName() != path[0] {
+			continue
+		}
+		if len(path) > 1 {
+			markIndexed(fields[i].Fields, path[1:], unique)
+		} else if unique {
+			fields[i].Unique = true
+		} else {
+			fields[i].Index = true
+		}
+		return
+	}
+}
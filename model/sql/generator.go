@@ -0,0 +1,140 @@
+package sql
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lewinz/go-gen/generator"
+	"github.com/lewinz/go-gen/util/ddl"
+	"github.com/lewinz/go-gen/util/field"
+	"github.com/lewinz/go-gen/util/template"
+)
+
+// ErrUnsupported reports a table that the models cannot represent, such as a table with
+// a composite primary key
+var ErrUnsupported = errors.New("not supported")
+
+// SQLGenerator is a MySQL or PostgreSQL model generator
+type SQLGenerator struct {
+	*generator.BaseGenerator
+	dialect ddl.Dialect
+	engine  *template.Engine
+}
+
+// NewSQLGenerator creates a new generator for the SQL dialect. Fields without a tag
+// use their snake case name as column. A model without a primary key, such as the
+// model of a table without one, has no Update, Delete and FindById methods
+func NewSQLGenerator(base *generator.BaseGenerator, dialect ddl.Dialect) *SQLGenerator {
+	// If file style is not specified, use snake case by default
+	if base.FileStyle == "" {
+		base.FileStyle = "snake"
+	}
+	base.Fields = withColumns(base.Fields)
+	engine := base.NewEngine(string(dialect))
+	return &SQLGenerator{
+		BaseGenerator: base,
+		dialect:       dialect,
+		engine:        engine,
+	}
+}
+
+// Generate implements SQL model generation
+func (g *SQLGenerator) Generate() error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+
+	// Ensure output directory exists, previews leave the disk untouched
+	if !g.DryRun && !g.Diff {
+		if err := os.MkdirAll(g.OutputDir, 0755); err != nil {
+			return fmt.Errorf("create output directory: %w", err)
+		}
+	}
+
+	// Generate code using template engine
+	return g.engine.Generate(g.TemplateDir, g.OutputDir, g.Type)
+}
+
+// Validate implements SQL-specific parameter validation
+func (g *SQLGenerator) Validate() error {
+	if err := g.BaseGenerator.Validate(); err != nil {
+		return err
+	}
+
+	// Validate dialect
+	if g.dialect != ddl.MySQL && g.dialect != ddl.Postgres {
+		return fmt.Errorf("invalid SQL dialect: %s", g.dialect)
+	}
+
+	// Validate naming styles
+	if !g.ValidStyle() {
+		return fmt.Errorf("invalid file style: %s", g.FileStyle)
+	}
+
+	// Validate conflict policy
+	if _, err := template.ParseConflictPolicy(g.OnConflict); err != nil {
+		return err
+	}
+
+	// Validate fields, columns are flat and the model needs a single primary key
+	if err := field.Validate(g.Fields); err != nil {
+		return err
+	}
+	var primaryKey []string
+	for _, f := range g.Fields {
+		if len(f.Fields) > 0 {
+			return fmt.Errorf("field %s: nested fields are not supported by SQL models", f.NamePascal())
+		}
+		if f.Default && ddl.Nilable(f.Type) != f.Type {
+			return fmt.Errorf("field %s: a column with a default needs a type that can be nil, e.g. %s", f.NamePascal(), ddl.Nilable(f.Type))
+		}
+		if f.PrimaryKey {
+			primaryKey = append(primaryKey, f.TagName())
+		}
+	}
+	if len(primaryKey) > 1 {
+		return fmt.Errorf("composite primary key (%s) is %w", strings.Join(primaryKey, ", "), ErrUnsupported)
+	}
+
+	// Validate template directory
+	if !g.ValidTemplatePath() {
+		return fmt.Errorf("invalid template path: %s", g.TemplateDir)
+	}
+
+	return nil
+}
+
+// withColumns returns the fields with their column names as tags
+func withColumns(fields []field.Field) []field.Field {
+	result := make([]field.Field, 0, len(fields))
+	for _, f := range fields {
+		if f.Tag == "" {
+			f.Tag = f.NameSnake()
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// WithPrimaryKey returns the fields of a model that is not read from a table, which
+// needs a primary key: a field named id becomes the primary key, otherwise an
+// auto-increment id is added. Tables are used as they are, their columns must exist
+func WithPrimaryKey(fields []field.Field) []field.Field {
+	result := make([]field.Field, 0, len(fields)+1)
+	for _, f := range fields {
+		if f.PrimaryKey {
+			return fields
+		}
+	}
+	for i, f := range fields {
+		if f.NamePascal() == "Id" {
+			result = append(result, fields...)
+			result[i].PrimaryKey = true
+			return result
+		}
+	}
+	id := field.Field{Name: "id", Type: "int64", Tag: "id", PrimaryKey: true, AutoIncrement: true}
+	return append(append(result, id), fields...)
+}
@@ -0,0 +1,128 @@
+package sql
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lewinz/go-gen/generator"
+	"github.com/lewinz/go-gen/util/ddl"
+	"github.com/lewinz/go-gen/util/field"
+	"github.com/lewinz/go-gen/util/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLGenerator(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "model")
+	base := generator.NewBaseGenerator("user", outputDir, template.BuiltinTemplate, "")
+	base.Fields = WithPrimaryKey([]field.Field{{Name: "email", Type: "string"}})
+
+	err := NewSQLGenerator(base, ddl.Postgres).Generate()
+	assert.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "`INSERT INTO \"users\" (\"email\") VALUES ($1) RETURNING \"id\"`")
+
+	// Without a primary key, the model only inserts and searches rows
+	base = generator.NewBaseGenerator("event", outputDir, template.BuiltinTemplate, "")
+	base.Fields = []field.Field{{Name: "name", Type: "string", Index: true}, {Name: "id", Type: "int64"}}
+	err = NewSQLGenerator(base, ddl.MySQL).Generate()
+	assert.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(outputDir, "event_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "\"INSERT INTO `events` (`name`, `id`) VALUES (?, ?)\",")
+	assert.Contains(t, string(content), "\tEventCond struct {\n\t\tName *string\n\t}\n")
+	for _, method := range []string{"Update(", "Delete(", "FindById("} {
+		assert.NotContains(t, string(content), method)
+	}
+}
+
+func TestSQLGeneratorValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		dialect     ddl.Dialect
+		fileStyle   string
+		fields      []field.Field
+		expectError string
+	}{
+		{name: "valid", dialect: ddl.MySQL, fields: []field.Field{{Name: "email", Type: "string"}}},
+		{name: "invalid dialect", dialect: "sqlite", expectError: "invalid SQL dialect: sqlite"},
+		{name: "invalid file style", dialect: ddl.MySQL, fileStyle: "upper", expectError: "invalid file style: upper"},
+		{name: "invalid field", dialect: ddl.MySQL, fields: []field.Field{{Name: "email", Type: "1"}}, expectError: "invalid type"},
+		{
+			name:        "nested fields",
+			dialect:     ddl.MySQL,
+			fields:      []field.Field{{Name: "address", Type: "Address", Fields: []field.Field{{Name: "city", Type: "string"}}}},
+			expectError: "field Address: nested fields are not supported by SQL models",
+		},
+		{
+			name:        "default without nil",
+			dialect:     ddl.MySQL,
+			fields:      []field.Field{{Name: "active", Type: "bool", Default: true}},
+			expectError: "field Active: a column with a default needs a type that can be nil, e.g. *bool",
+		},
+		{
+			name:    "composite primary key",
+			dialect: ddl.Postgres,
+			fields: []field.Field{
+				{Name: "user_id", Type: "int64", PrimaryKey: true},
+				{Name: "group_id", Type: "int64", PrimaryKey: true},
+			},
+			expectError: "composite primary key (user_id, group_id) is not supported",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			base := generator.NewBaseGenerator("user", t.TempDir(), template.BuiltinTemplate, tc.fileStyle)
+			base.Fields = tc.fields
+			err := NewSQLGenerator(base, tc.dialect).Validate()
+			if tc.expectError != "" {
+				assert.ErrorContains(t, err, tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWithColumns(t *testing.T) {
+	fields := []field.Field{{Name: "createdBy", Type: "string"}, {Name: "code", Type: "string", Tag: "Code", PrimaryKey: true}}
+	assert.Equal(t, []field.Field{
+		{Name: "createdBy", Type: "string", Tag: "created_by"},
+		{Name: "code", Type: "string", Tag: "Code", PrimaryKey: true},
+	}, withColumns(fields))
+}
+
+func TestWithPrimaryKey(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fields   []field.Field
+		expected []field.Field
+	}{
+		{
+			name:   "adds an id",
+			fields: []field.Field{{Name: "createdBy", Type: "string"}},
+			expected: []field.Field{
+				{Name: "id", Type: "int64", Tag: "id", PrimaryKey: true, AutoIncrement: true},
+				{Name: "createdBy", Type: "string"},
+			},
+		},
+		{
+			name:     "uses the id field",
+			fields:   []field.Field{{Name: "ID", Type: "string"}},
+			expected: []field.Field{{Name: "ID", Type: "string", PrimaryKey: true}},
+		},
+		{
+			name:     "keeps the primary key",
+			fields:   []field.Field{{Name: "code", Type: "string", Tag: "Code", PrimaryKey: true}, {Name: "id", Type: "int"}},
+			expected: []field.Field{{Name: "code", Type: "string", Tag: "Code", PrimaryKey: true}, {Name: "id", Type: "int"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, WithPrimaryKey(tc.fields))
+		})
+	}
+}
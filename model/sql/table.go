@@ -0,0 +1,84 @@
+package sql
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/lewinz/go-gen/util/ddl"
+	"github.com/lewinz/go-gen/util/naming"
+)
+
+// LoadTables reads the tables defined by DDL files
+func LoadTables(paths []string) ([]*ddl.Table, error) {
+	var tables []*ddl.Table
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		parsed, err := ddl.Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		tables = append(tables, parsed...)
+	}
+	return tables, nil
+}
+
+// FindTable returns the table of a model named after the type in snake case or its
+// plural, or the only table defined
+func FindTable(tables []*ddl.Table, typeName string) (*ddl.Table, error) {
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no CREATE TABLE statement found")
+	}
+	snake := naming.NewConverter(naming.StyleSnake).Convert(typeName)
+	for _, candidate := range []string{snake, naming.Pluralize(snake)} {
+		for _, table := range tables {
+			if strings.EqualFold(table.Name, candidate) {
+				return table, nil
+			}
+		}
+	}
+	if len(tables) == 1 {
+		return tables[0], nil
+	}
+	return nil, fmt.Errorf("no table matches type %s, use --table to choose one of %s", typeName, tableNames(tables))
+}
+
+// MatchTables returns the tables whose names match any of the patterns, which are
+// names or globs such as user_*
+func MatchTables(tables []*ddl.Table, patterns []string) ([]*ddl.Table, error) {
+	var matched []*ddl.Table
+	for _, table := range tables {
+		for _, pattern := range patterns {
+			ok, err := path.Match(pattern, table.Name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid table pattern %q: %w", pattern, err)
+			}
+			if ok {
+				matched = append(matched, table)
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("no table matches %s, tables are %s", strings.Join(patterns, ", "), tableNames(tables))
+	}
+	return matched, nil
+}
+
+// TypeName returns the model type of a table, the singular of its name
+func TypeName(table *ddl.Table) string {
+	return naming.Singularize(table.Name)
+}
+
+// tableNames returns the names of the tables as a comma separated list
+func tableNames(tables []*ddl.Table) string {
+	names := make([]string, len(tables))
+	for i, table := range tables {
+		names[i] = table.Name
+	}
+	return strings.Join(names, ", ")
+}
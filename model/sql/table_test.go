@@ -0,0 +1,100 @@
+package sql
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lewinz/go-gen/util/ddl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTables(t *testing.T) {
+	dir := t.TempDir()
+	users := filepath.Join(dir, "users.sql")
+	assert.NoError(t, os.WriteFile(users, []byte("CREATE TABLE users (id int PRIMARY KEY);"), 0644))
+	posts := filepath.Join(dir, "posts.sql")
+	assert.NoError(t, os.WriteFile(posts, []byte("CREATE TABLE posts (id int PRIMARY KEY);"), 0644))
+	broken := filepath.Join(dir, "broken.sql")
+	assert.NoError(t, os.WriteFile(broken, []byte("CREATE TABLE broken (id int"), 0644))
+
+	tables, err := LoadTables([]string{users, posts})
+	assert.NoError(t, err)
+	assert.Len(t, tables, 2)
+	assert.Equal(t, "users", tables[0].Name)
+	assert.Equal(t, "posts", tables[1].Name)
+
+	_, err = LoadTables([]string{broken})
+	assert.ErrorContains(t, err, "parse "+broken)
+	_, err = LoadTables([]string{filepath.Join(dir, "missing.sql")})
+	assert.ErrorContains(t, err, "read ")
+}
+
+func TestFindTable(t *testing.T) {
+	tables := []*ddl.Table{{Name: "users"}, {Name: "user_profile"}, {Name: "Orders"}}
+
+	testCases := []struct {
+		name        string
+		tables      []*ddl.Table
+		typeName    string
+		expected    string
+		expectError string
+	}{
+		{name: "by singular type", tables: tables, typeName: "UserProfile", expected: "user_profile"},
+		{name: "by plural type", tables: tables, typeName: "user", expected: "users"},
+		{name: "case insensitive", tables: tables, typeName: "order", expected: "Orders"},
+		{name: "only table", tables: tables[:1], typeName: "account", expected: "users"},
+		{name: "no match", tables: tables, typeName: "account", expectError: "no table matches type account, use --table to choose one of users, user_profile, Orders"},
+		{name: "no tables", typeName: "user", expectError: "no CREATE TABLE statement found"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			table, err := FindTable(tc.tables, tc.typeName)
+			if tc.expectError != "" {
+				assert.EqualError(t, err, tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, table.Name)
+		})
+	}
+}
+
+func TestMatchTables(t *testing.T) {
+	tables := []*ddl.Table{{Name: "users"}, {Name: "user_logins"}, {Name: "orders"}, {Name: "order_items"}}
+
+	testCases := []struct {
+		name        string
+		patterns    []string
+		expected    []string
+		expectError string
+	}{
+		{name: "name", patterns: []string{"orders"}, expected: []string{"orders"}},
+		{name: "glob", patterns: []string{"user*"}, expected: []string{"users", "user_logins"}},
+		{name: "several patterns", patterns: []string{"order_*", "users"}, expected: []string{"users", "order_items"}},
+		{name: "no match", patterns: []string{"accounts", "team_*"}, expectError: "no table matches accounts, team_*, tables are users, user_logins, orders, order_items"},
+		{name: "invalid pattern", patterns: []string{"user["}, expectError: `invalid table pattern "user["`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			matched, err := MatchTables(tables, tc.patterns)
+			if tc.expectError != "" {
+				assert.ErrorContains(t, err, tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			var names []string
+			for _, table := range matched {
+				names = append(names, table.Name)
+			}
+			assert.Equal(t, tc.expected, names)
+		})
+	}
+}
+
+func TestTypeName(t *testing.T) {
+	assert.Equal(t, "user_login", TypeName(&ddl.Table{Name: "user_logins"}))
+	assert.Equal(t, "category", TypeName(&ddl.Table{Name: "categories"}))
+}
@@ -5,23 +5,55 @@ import (
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	{{- /* Named imports are removed when unused, e.g. without ObjectID fields or unique indexes */}}
+	primitive "go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	options "go.mongodb.org/mongo-driver/mongo/options"
 )
+{{- $indexed := false}}
+{{- range .Fields}}{{if .Indexed}}{{$indexed = true}}{{end}}{{end}}
+{{- /* The type of _id, e.g. primitive.ObjectID when inferred, string ids are generated as ObjectID hex */}}
+{{- $idType := default "string" .Vars.idType}}
 
 type (
 	{{.TypePascal}} struct {
-		Id          string    `bson:"_id,omitempty" json:"id,omitempty"`
-		// TOTO: Add your fields here
+		Id          {{$idType}} `bson:"_id,omitempty" json:"id,omitempty"`
+		{{- range .Fields}}
+		{{- if .Comment}}
+		// {{.Comment}}
+		{{- end}}
+		{{.NamePascal}} {{.Type}} `{{.Tags}}`
+		{{- end}}
+		// go-gen:begin fields
+		// Add your fields here, they are kept when the model is regenerated
+		// go-gen:end fields
 		CreatedTime time.Time `bson:"createdTime"   json:"createdTime"`
 		UpdatedTime time.Time `bson:"updatedTime"   json:"updatedTime"`
 	}
+	{{- range .Structs}}
+
+	{{.Name}} struct {
+		{{- range .Fields}}
+		{{- if .Comment}}
+		// {{.Comment}}
+		{{- end}}
+		{{.NamePascal}} {{.Type}} `{{.Tags}}`
+		{{- end}}
+	}
+	{{- end}}
 
 	{{.TypePascal}}Model interface {
 		Insert(ctx context.Context, {{.TypeCamel}} *{{.TypePascal}}) error
 		Update(ctx context.Context, {{.TypeCamel}} *{{.TypePascal}}) error
-		Delete(ctx context.Context, id string) error
-		FindById(ctx context.Context, id string) (*{{.TypePascal}}, error)
+		Delete(ctx context.Context, id {{$idType}}) error
+		FindById(ctx context.Context, id {{$idType}}) (*{{.TypePascal}}, error)
 		Search(ctx context.Context, cond *{{.TypePascal}}Cond) ([]*{{.TypePascal}}, error)
+		{{- range .Fields}}{{if .Unique}}
+		FindBy{{.NamePascal}}(ctx context.Context, value {{.Type}}) (*{{$.TypePascal}}, error)
+		{{- end}}{{end}}
+		{{- if $indexed}}
+		EnsureIndexes(ctx context.Context) error
+		{{- end}}
 	}
 
 	default{{.TypePascal}}Model struct {
@@ -29,18 +61,30 @@ type (
 	}
 
 	{{.TypePascal}}Cond struct {
-		Id  string
-		Ids []string
+		Id  {{$idType}}
+		Ids []{{$idType}}
+		{{- range .Fields}}{{if .Indexed}}
+		{{.NamePascal}} {{if hasPrefix "*" .Type}}{{.Type}}{{else}}*{{.Type}}{{end}}
+		{{- end}}{{end}}
 	}
 )
 
 func New{{.TypePascal}}Model(db *mongo.Database) {{.TypePascal}}Model {
 	return &default{{.TypePascal}}Model{
-		model: db.Collection("{{.TypeSnake}}"),
+		model: db.Collection("{{default .TypeSnake .Vars.collection}}"),
 	}
 }
 
 func (m *default{{.TypePascal}}Model) Insert(ctx context.Context, {{.TypeCamel}} *{{.TypePascal}}) error {
+	{{- if eq $idType "string"}}
+	if {{.TypeCamel}}.Id == "" {
+		{{.TypeCamel}}.Id = primitive.NewObjectID().Hex()
+	}
+	{{- else if eq $idType "primitive.ObjectID"}}
+	if {{.TypeCamel}}.Id.IsZero() {
+		{{.TypeCamel}}.Id = primitive.NewObjectID()
+	}
+	{{- end}}
 	{{.TypeCamel}}.CreatedTime = time.Now()
 	{{.TypeCamel}}.UpdatedTime = time.Now()
 	
@@ -59,12 +103,12 @@ func (m *default{{.TypePascal}}Model) Update(ctx context.Context, {{.TypeCamel}}
 	return err
 }
 
-func (m *default{{.TypePascal}}Model) Delete(ctx context.Context, id string) error {
+func (m *default{{.TypePascal}}Model) Delete(ctx context.Context, id {{$idType}}) error {
 	_, err := m.model.DeleteOne(ctx, bson.M{"_id": id})
 	return err
 }
 
-func (m *default{{.TypePascal}}Model) FindById(ctx context.Context, id string) (*{{.TypePascal}}, error) {
+func (m *default{{.TypePascal}}Model) FindById(ctx context.Context, id {{$idType}}) (*{{.TypePascal}}, error) {
 	var {{.TypeCamel}} {{.TypePascal}}
 	err := m.model.FindOne(ctx, bson.M{"_id": id}).Decode(&{{.TypeCamel}})
 	if err != nil {
@@ -72,15 +116,35 @@ func (m *default{{.TypePascal}}Model) FindById(ctx context.Context, id string) (
 	}
 	return &{{.TypeCamel}}, nil
 }
+{{- range .Fields}}{{if .Unique}}
+
+func (m *default{{$.TypePascal}}Model) FindBy{{.NamePascal}}(ctx context.Context, value {{.Type}}) (*{{$.TypePascal}}, error) {
+	var {{$.TypeCamel}} {{$.TypePascal}}
+	err := m.model.FindOne(ctx, bson.M{"{{.TagName}}": value}).Decode(&{{$.TypeCamel}})
+	if err != nil {
+		return nil, err
+	}
+	return &{{$.TypeCamel}}, nil
+}
+{{- end}}{{end}}
 
 func (c *{{.TypePascal}}Cond) genCond() bson.M {
 	filter := bson.M{}
-
+{{if eq $idType "string"}}
 	if c.Id != "" {
+	{{- else}}
+	var zeroId {{$idType}}
+	if c.Id != zeroId {
+	{{- end}}
 		filter["_id"] = c.Id
 	} else if len(c.Ids) > 0 {
 		filter["_id"] = bson.M{"$in": c.Ids}
 	}
+	{{- range .Fields}}{{if .Indexed}}
+	if c.{{.NamePascal}} != nil {
+		filter["{{.TagName}}"] = *c.{{.NamePascal}}
+	}
+	{{- end}}{{end}}
 
 	return filter
 }
@@ -99,4 +163,23 @@ func (m *default{{.TypePascal}}Model) Search(ctx context.Context, cond *{{.TypeP
 		return nil, err
 	}
 	return result, nil
-} 
\ No newline at end of file
+}
+{{- if $indexed}}
+
+func (m *default{{.TypePascal}}Model) EnsureIndexes(ctx context.Context) error {
+	_, err := m.model.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{{- range .Fields}}
+		{{- if .Unique}}
+		{Keys: bson.D{ {Key: "{{.TagName}}", Value: 1} }, Options: options.Index().SetUnique(true)},
+		{{- else if .Index}}
+		{Keys: bson.D{ {Key: "{{.TagName}}", Value: 1} }},
+		{{- end}}
+		{{- end}}
+	})
+	return err
+}
+{{- end}}
+
+// go-gen:begin methods
+// Add your methods here, they are kept when the model is regenerated
+// go-gen:end methods
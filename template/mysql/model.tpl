@@ -0,0 +1,280 @@
+package {{.PackageName}}
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+{{- $table := default (.TypeSnake | plural) .Vars.table}}
+{{- $pk := ""}}
+{{- $columns := ""}}
+{{- $insertColumns := ""}}
+{{- $insertValues := ""}}
+{{- $updateSet := ""}}
+{{- $defaults := false}}
+{{- $fixedColumns := ""}}
+{{- $updateDefaults := false}}
+{{- $fixedSet := ""}}
+{{- range .Fields}}
+{{- $columns = printf "%s, `%s`" $columns .TagName}}
+{{- if .PrimaryKey}}{{$pk = .}}{{end}}
+{{- if .Writable}}
+{{- $insertColumns = printf "%s, `%s`" $insertColumns .TagName}}
+{{- $insertValues = printf "%s, ?" $insertValues}}
+{{- if .Default}}{{$defaults = true}}{{else}}{{$fixedColumns = printf "%s, \"`%s`\"" $fixedColumns .TagName}}{{end}}
+{{- if not .PrimaryKey}}
+{{- $updateSet = printf "%s, `%s` = ?" $updateSet .TagName}}
+{{- if .Default}}{{$updateDefaults = true}}{{else}}{{$fixedSet = printf "%s, \"`%s` = ?\"" $fixedSet .TagName}}{{end}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- $columns = trimPrefix ", " $columns}}
+{{- $insertColumns = trimPrefix ", " $insertColumns}}
+{{- $insertValues = trimPrefix ", " $insertValues}}
+{{- $updateSet = trimPrefix ", " $updateSet}}
+{{- $fixedColumns = trimPrefix ", " $fixedColumns}}
+{{- $fixedSet = trimPrefix ", " $fixedSet}}
+
+type (
+	{{.TypePascal}} struct {
+		{{- range .Fields}}
+		{{- if .Comment}}
+		// {{.Comment}}
+		{{- end}}
+		{{.NamePascal}} {{.Type}} `db:"{{.TagName}}" json:"{{.NameCamel}}{{if .Optional}},omitempty{{end}}"`
+		{{- end}}
+		// go-gen:begin fields
+		// Add your fields here, they are kept when the model is regenerated
+		// go-gen:end fields
+	}
+
+	{{.TypePascal}}Model interface {
+		Insert(ctx context.Context, {{.TypeCamel}} *{{.TypePascal}}) error
+		{{- /* Rows of tables without a primary key can only be inserted and searched */}}
+		{{- if $pk}}
+		Update(ctx context.Context, {{.TypeCamel}} *{{.TypePascal}}) error
+		Delete(ctx context.Context, id {{$pk.Type}}) error
+		FindById(ctx context.Context, id {{$pk.Type}}) (*{{.TypePascal}}, error)
+		{{- end}}
+		Search(ctx context.Context, cond *{{.TypePascal}}Cond) ([]*{{.TypePascal}}, error)
+		{{- range .Fields}}{{if and .Unique (not .PrimaryKey)}}
+		FindBy{{.NamePascal}}(ctx context.Context, value {{.Type}}) (*{{$.TypePascal}}, error)
+		{{- end}}{{end}}
+	}
+
+	default{{.TypePascal}}Model struct {
+		db *sql.DB
+	}
+
+	{{.TypePascal}}Cond struct {
+		{{- if $pk}}
+		Id  *{{$pk.Type}}
+		Ids []{{$pk.Type}}
+		{{- end}}
+		{{- range .Fields}}{{if and .Indexed (not .PrimaryKey)}}
+		{{.NamePascal}} {{if hasPrefix "*" .Type}}{{.Type}}{{else}}*{{.Type}}{{end}}
+		{{- end}}{{end}}
+	}
+)
+
+func New{{.TypePascal}}Model(db *sql.DB) {{.TypePascal}}Model {
+	return &default{{.TypePascal}}Model{
+		db: db,
+	}
+}
+
+func (m *default{{.TypePascal}}Model) Insert(ctx context.Context, {{.TypeCamel}} *{{.TypePascal}}) error {
+	{{- $stamped := false}}
+	{{- range .Fields}}{{if and (or (eq .Type "time.Time") (and .Default (eq .Type "*time.Time"))) (eq .NameSnake "created_at" "created_time" "create_time" "updated_at" "updated_time" "update_time")}}
+	{{- if not $stamped}}
+	now := time.Now()
+	{{- end}}{{$stamped = true}}
+	{{$.TypeCamel}}.{{.NamePascal}} = {{if hasPrefix "*" .Type}}&now{{else}}now{{end}}
+	{{- end}}{{end}}
+	{{- if $stamped}}
+{{end}}
+	{{- if $defaults}}
+	{{- /* Columns with a default are left to the database while their fields are nil */}}
+	columns := []string{ {{- $fixedColumns -}} }
+	args := []interface{}{
+		{{- range .Fields}}{{if and .Writable (not .Default)}}
+		{{$.TypeCamel}}.{{.NamePascal}},
+		{{- end}}{{end}}
+	}
+	{{- range .Fields}}{{if and .Writable .Default}}
+	if {{$.TypeCamel}}.{{.NamePascal}} != nil {
+		columns = append(columns, "`{{.TagName}}`")
+		args = append(args, {{$.TypeCamel}}.{{.NamePascal}})
+	}
+	{{- end}}{{end}}
+
+	query := "INSERT INTO `{{$table}}` () VALUES ()"
+	if len(columns) > 0 {
+		query = "INSERT INTO `{{$table}}` (" + strings.Join(columns, ", ") + ") VALUES (?" + strings.Repeat(", ?", len(columns)-1) + ")"
+	}
+	{{if and $pk $pk.AutoIncrement}}result, err :={{else}}_, err :={{end}} m.db.ExecContext(ctx, query, args...)
+	{{- else}}
+	{{if and $pk $pk.AutoIncrement}}result, err :={{else}}_, err :={{end}} m.db.ExecContext(ctx,
+		{{- if $insertColumns}}
+		"INSERT INTO `{{$table}}` ({{$insertColumns}}) VALUES ({{$insertValues}})",
+		{{- range .Fields}}{{if .Writable}}
+		{{$.TypeCamel}}.{{.NamePascal}},
+		{{- end}}{{end}}
+		{{- else}}
+		"INSERT INTO `{{$table}}` () VALUES ()",
+		{{- end}}
+	)
+	{{- end}}
+	{{- if and $pk $pk.AutoIncrement}}
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	{{.TypeCamel}}.{{$pk.NamePascal}} = {{$pk.Type}}(id)
+	return nil
+	{{- else}}
+	return err
+	{{- end}}
+}
+
+{{- if $pk}}
+
+func (m *default{{.TypePascal}}Model) Update(ctx context.Context, {{.TypeCamel}} *{{.TypePascal}}) error {
+	{{- if $updateSet}}
+	{{- $stamped := false}}
+	{{- range .Fields}}{{if and (or (eq .Type "time.Time") (and .Default (eq .Type "*time.Time"))) (eq .NameSnake "updated_at" "updated_time" "update_time")}}
+	{{- if not $stamped}}
+	now := time.Now()
+	{{- end}}{{$stamped = true}}
+	{{$.TypeCamel}}.{{.NamePascal}} = {{if hasPrefix "*" .Type}}&now{{else}}now{{end}}
+	{{- end}}{{end}}
+	{{- if $stamped}}
+{{end}}
+	{{- if $updateDefaults}}
+	{{- /* Columns with a default keep their value while their fields are nil */}}
+	set := []string{ {{- $fixedSet -}} }
+	args := []interface{}{
+		{{- range .Fields}}{{if and .Writable (not .PrimaryKey) (not .Default)}}
+		{{$.TypeCamel}}.{{.NamePascal}},
+		{{- end}}{{end}}
+	}
+	{{- range .Fields}}{{if and .Writable (not .PrimaryKey) .Default}}
+	if {{$.TypeCamel}}.{{.NamePascal}} != nil {
+		set = append(set, "`{{.TagName}}` = ?")
+		args = append(args, {{$.TypeCamel}}.{{.NamePascal}})
+	}
+	{{- end}}{{end}}
+	{{- if not $fixedSet}}
+	if len(set) == 0 {
+		return nil
+	}
+	{{- end}}
+
+	args = append(args, {{.TypeCamel}}.{{$pk.NamePascal}})
+	_, err := m.db.ExecContext(ctx, "UPDATE `{{$table}}` SET "+strings.Join(set, ", ")+" WHERE `{{$pk.TagName}}` = ?", args...)
+	return err
+	{{- else}}
+	_, err := m.db.ExecContext(ctx,
+		"UPDATE `{{$table}}` SET {{$updateSet}} WHERE `{{$pk.TagName}}` = ?",
+		{{- range .Fields}}{{if and .Writable (not .PrimaryKey)}}
+		{{$.TypeCamel}}.{{.NamePascal}},
+		{{- end}}{{end}}
+		{{.TypeCamel}}.{{$pk.NamePascal}},
+	)
+	return err
+	{{- end}}
+	{{- else}}
+	return nil
+	{{- end}}
+}
+
+func (m *default{{.TypePascal}}Model) Delete(ctx context.Context, id {{$pk.Type}}) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM `{{$table}}` WHERE `{{$pk.TagName}}` = ?", id)
+	return err
+}
+
+func (m *default{{.TypePascal}}Model) FindById(ctx context.Context, id {{$pk.Type}}) (*{{.TypePascal}}, error) {
+	return m.findOne(ctx, "SELECT {{$columns}} FROM `{{$table}}` WHERE `{{$pk.TagName}}` = ? LIMIT 1", id)
+}
+{{- end}}
+{{- range .Fields}}{{if and .Unique (not .PrimaryKey)}}
+
+func (m *default{{$.TypePascal}}Model) FindBy{{.NamePascal}}(ctx context.Context, value {{.Type}}) (*{{$.TypePascal}}, error) {
+	return m.findOne(ctx, "SELECT {{$columns}} FROM `{{$table}}` WHERE `{{.TagName}}` = ? LIMIT 1", value)
+}
+{{- end}}{{end}}
+
+func (c *{{.TypePascal}}Cond) genCond() (string, []interface{}) {
+	var where []string
+	var args []interface{}
+{{if $pk}}
+	if c.Id != nil {
+		where = append(where, "`{{$pk.TagName}}` = ?")
+		args = append(args, *c.Id)
+	} else if len(c.Ids) > 0 {
+		where = append(where, "`{{$pk.TagName}}` IN (?"+strings.Repeat(", ?", len(c.Ids)-1)+")")
+		for _, id := range c.Ids {
+			args = append(args, id)
+		}
+	}
+	{{- end}}
+	{{- range .Fields}}{{if and .Indexed (not .PrimaryKey)}}
+	if c.{{.NamePascal}} != nil {
+		where = append(where, "`{{.TagName}}` = ?")
+		args = append(args, *c.{{.NamePascal}})
+	}
+	{{- end}}{{end}}
+
+	if len(where) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(where, " AND "), args
+}
+
+func (m *default{{.TypePascal}}Model) Search(ctx context.Context, cond *{{.TypePascal}}Cond) ([]*{{.TypePascal}}, error) {
+	where, args := cond.genCond()
+
+	rows, err := m.db.QueryContext(ctx, "SELECT {{$columns}} FROM `{{$table}}`"+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*{{.TypePascal}}
+	for rows.Next() {
+		{{.TypeCamel}}, err := m.scan(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, {{.TypeCamel}})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (m *default{{.TypePascal}}Model) findOne(ctx context.Context, query string, args ...interface{}) (*{{.TypePascal}}, error) {
+	return m.scan(m.db.QueryRowContext(ctx, query, args...).Scan)
+}
+
+func (m *default{{.TypePascal}}Model) scan(scan func(dest ...interface{}) error) (*{{.TypePascal}}, error) {
+	var {{.TypeCamel}} {{.TypePascal}}
+	err := scan(
+		{{- range .Fields}}
+		&{{$.TypeCamel}}.{{.NamePascal}},
+		{{- end}}
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.TypeCamel}}, nil
+}
+
+// go-gen:begin methods
+// Add your methods here, they are kept when the model is regenerated
+// go-gen:end methods
@@ -0,0 +1,13 @@
+// Package template contains the built-in templates of go-gen.
+//
+// The templates are embedded into the binary, so generating code with them
+// needs neither network access nor a Git checkout.
+package template
+
+import "embed"
+
+// FS holds the built-in template pack. Its root contains the go-gen.yaml
+// manifest and one directory per generator.
+//
+//go:embed go-gen.yaml all:mongo all:mysql all:postgres
+var FS embed.FS
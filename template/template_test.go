@@ -0,0 +1,15 @@
+package template
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFS(t *testing.T) {
+	for _, name := range []string{"go-gen.yaml", "mongo/model.tpl", "mysql/model.tpl", "postgres/model.tpl"} {
+		_, err := fs.Stat(FS, name)
+		assert.NoError(t, err, name)
+	}
+}
@@ -0,0 +1,721 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Table is a table defined by CREATE TABLE
+type Table struct {
+	Schema     string   // Schema or database of a qualified table name, empty if not qualified
+	Name       string   // Table name
+	Comment    string   // Table comment
+	Columns    []Column // Columns in the order they are defined
+	PrimaryKey []string // Columns of the primary key
+	Indexes    []Index  // Indexes and unique constraints other than the primary key
+}
+
+// Column is a column of a table
+type Column struct {
+	Name          string   // Column name
+	Type          string   // Type name in lower case, e.g. varchar or timestamp with time zone
+	Args          []string // Type arguments, e.g. 255 for varchar(255)
+	Unsigned      bool     // MySQL unsigned integer
+	Array         bool     // PostgreSQL array
+	Nullable      bool     // NULL is allowed
+	Default       string   // Default value as written, empty if there is none
+	AutoIncrement bool     // Assigned by the database: auto_increment, serial, identity or a sequence default
+	Computed      bool     // Generated column computed from other columns
+	Comment       string   // Column comment
+}
+
+// Index is an index or unique constraint of a table
+type Index struct {
+	Name    string   // Index name, empty if not named
+	Columns []string // Indexed columns, expression indexes are not included
+	Unique  bool     // Unique index or constraint
+}
+
+// Column returns the column with the given name, nil if there is none
+func (t *Table) Column(name string) *Column {
+	for i := range t.Columns {
+		if strings.EqualFold(t.Columns[i].Name, name) {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+// Parse parses the CREATE TABLE statements of MySQL or PostgreSQL DDL, such as the
+// output of mysqldump --no-data or pg_dump --schema-only. CREATE INDEX, COMMENT ON and
+// the ALTER TABLE statements that add constraints, defaults and identities are applied
+// to the tables, other statements are ignored
+func Parse(src string) ([]*Table, error) {
+	statements, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []*Table
+	find := func(name []string) *Table {
+		for _, t := range tables {
+			if strings.EqualFold(t.Name, name[len(name)-1]) {
+				return t
+			}
+		}
+		return nil
+	}
+
+	// Tables are created first, other statements may refer to tables defined after them
+	var others []*parser
+	for _, tokens := range statements {
+		p := &parser{src: src, tokens: tokens}
+		if !p.isCreateTable() {
+			others = append(others, p)
+			continue
+		}
+		table, err := p.createTable()
+		if err != nil {
+			return nil, err
+		}
+		if table != nil {
+			tables = append(tables, table)
+		}
+	}
+	for _, p := range others {
+		if err := p.alter(find); err != nil {
+			return nil, err
+		}
+	}
+	return tables, nil
+}
+
+// ParseColumnType parses the type of a column as written in DDL or reported by the
+// database, e.g. varchar(255), int unsigned or timestamp(3) with time zone
+func ParseColumnType(typ string) (Column, error) {
+	statements, err := lex(typ)
+	if err != nil {
+		return Column{}, err
+	}
+	if len(statements) != 1 {
+		return Column{}, fmt.Errorf("invalid column type %q", typ)
+	}
+	var column Column
+	p := &parser{src: typ, tokens: statements[0]}
+	if err := p.columnType(&column); err != nil {
+		return Column{}, fmt.Errorf("invalid column type %q: %w", typ, err)
+	}
+	return column, nil
+}
+
+// parser parses a single statement
+type parser struct {
+	src    string // Source of all statements, the offsets of tokens refer to it
+	tokens []token
+	pos    int
+}
+
+// peek returns the token at offset n from the current one, an empty punctuation at the end
+func (p *parser) peek(n int) token {
+	if p.pos+n < len(p.tokens) {
+		return p.tokens[p.pos+n]
+	}
+	return token{kind: tokenPunct}
+}
+
+// next returns the current token and advances to the next one
+func (p *parser) next() token {
+	t := p.peek(0)
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return t
+}
+
+// done checks if all tokens were consumed
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+// accept consumes the keywords if the next tokens are these keywords
+func (p *parser) accept(keywords ...string) bool {
+	for i, keyword := range keywords {
+		if !p.peek(i).is(keyword) {
+			return false
+		}
+	}
+	p.pos += len(keywords)
+	return true
+}
+
+// acceptPunct consumes the punctuation if it is the next token
+func (p *parser) acceptPunct(punct string) bool {
+	if p.peek(0).isPunct(punct) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expectPunct consumes the punctuation or returns an error
+func (p *parser) expectPunct(punct string) error {
+	if !p.acceptPunct(punct) {
+		return p.errorf("expected %q", punct)
+	}
+	return nil
+}
+
+// errorf returns an error at the current token
+func (p *parser) errorf(format string, args ...interface{}) error {
+	t := p.peek(0)
+	at := "end of statement"
+	if !p.done() {
+		at = fmt.Sprintf("%q", t.text)
+	}
+	return fmt.Errorf("%s at %s", fmt.Sprintf(format, args...), at)
+}
+
+// name parses a possibly qualified name such as public.users
+func (p *parser) name() ([]string, error) {
+	var parts []string
+	for {
+		t := p.next()
+		if t.kind != tokenIdent && t.kind != tokenQuoted {
+			p.pos--
+			return nil, p.errorf("expected a name")
+		}
+		parts = append(parts, t.text)
+		if !p.acceptPunct(".") {
+			return parts, nil
+		}
+	}
+}
+
+// skipParens skips a parenthesized list if the next token opens one
+func (p *parser) skipParens() {
+	if !p.peek(0).isPunct("(") {
+		return
+	}
+	depth := 0
+	for !p.done() {
+		t := p.next()
+		switch {
+		case t.isPunct("("):
+			depth++
+		case t.isPunct(")"):
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// isCreateTable checks if the statement is CREATE TABLE
+func (p *parser) isCreateTable() bool {
+	for i := 0; i < len(p.tokens) && i < 6; i++ {
+		t := p.tokens[i]
+		switch {
+		case t.is("table"):
+			return p.tokens[0].is("create")
+		case !t.is("create", "or", "replace", "global", "local", "temporary", "temp", "unlogged"):
+			return false
+		}
+	}
+	return false
+}
+
+// createTable parses CREATE TABLE. It returns nil for CREATE TABLE ... AS and LIKE
+func (p *parser) createTable() (*Table, error) {
+	for !p.accept("table") {
+		p.next()
+	}
+	p.accept("if", "not", "exists")
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	table := &Table{Name: name[len(name)-1]}
+	if len(name) > 1 {
+		table.Schema = name[len(name)-2]
+	}
+	if !p.acceptPunct("(") {
+		return nil, nil
+	}
+
+	for {
+		if err := p.tableElement(table); err != nil {
+			return nil, fmt.Errorf("table %s: %w", table.Name, err)
+		}
+		if p.acceptPunct(")") {
+			break
+		}
+		if err := p.expectPunct(","); err != nil {
+			return nil, fmt.Errorf("table %s: %w", table.Name, err)
+		}
+	}
+
+	// Table options such as MySQL's ENGINE=InnoDB COMMENT='...'
+	for !p.done() {
+		if p.accept("comment") {
+			p.acceptPunct("=")
+			table.Comment = p.next().text
+			continue
+		}
+		p.next()
+	}
+
+	for _, name := range table.PrimaryKey {
+		if column := table.Column(name); column != nil {
+			column.Nullable = false
+		}
+	}
+	return table, nil
+}
+
+// tableElement parses a column definition or a table constraint
+func (p *parser) tableElement(table *Table) error {
+	t := p.peek(0)
+	if p.accept("constraint") {
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		return p.constraint(table, name[len(name)-1])
+	}
+	if t.is("primary", "unique", "key", "index", "fulltext", "spatial", "foreign", "check", "exclude", "like") &&
+		!p.peek(1).isPunct(",") && !p.peek(1).isPunct(")") {
+		return p.constraint(table, "")
+	}
+	return p.column(table)
+}
+
+// constraint parses a table constraint or a MySQL index definition,
+// name is the name given by CONSTRAINT
+func (p *parser) constraint(table *Table, name string) error {
+	switch {
+	case p.accept("primary", "key"):
+		columns, err := p.indexColumns()
+		if err != nil {
+			return err
+		}
+		table.PrimaryKey = columns
+	case p.peek(0).is("unique", "key", "index", "fulltext", "spatial"):
+		unique := p.accept("unique")
+		p.accept("fulltext")
+		p.accept("spatial")
+		if !p.accept("key") {
+			p.accept("index")
+		}
+		if !p.peek(0).isPunct("(") && !p.peek(0).is("using") {
+			parts, err := p.name()
+			if err != nil {
+				return err
+			}
+			name = parts[len(parts)-1]
+		}
+		if p.accept("using") {
+			p.next()
+		}
+		columns, err := p.indexColumns()
+		if err != nil {
+			return err
+		}
+		if columns != nil {
+			table.Indexes = append(table.Indexes, Index{Name: name, Columns: columns, Unique: unique})
+		}
+	}
+	// Skip the rest, e.g. foreign keys, checks and index options
+	for !p.done() && !p.peek(0).isPunct(",") && !p.peek(0).isPunct(")") {
+		if p.peek(0).isPunct("(") {
+			p.skipParens()
+		} else {
+			p.next()
+		}
+	}
+	return nil
+}
+
+// indexColumns parses the column list of an index. It returns nil for indexes on
+// expressions. MySQL prefix lengths and sort orders are dropped
+func (p *parser) indexColumns() ([]string, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	var columns []string
+	expression := false
+	for {
+		t := p.next()
+		switch {
+		case t.kind != tokenIdent && t.kind != tokenQuoted:
+			expression = true
+			p.pos--
+		case p.peek(0).isPunct("(") && p.peek(1).kind == tokenNumber && p.peek(2).isPunct(")"):
+			// Prefix length, e.g. name(10)
+			p.pos += 3
+			columns = append(columns, t.text)
+		case p.peek(0).isPunct("("):
+			expression = true
+		default:
+			columns = append(columns, t.text)
+		}
+		// Skip sort orders, operator classes and expressions up to the next column
+		depth := 0
+		for !p.done() {
+			t := p.peek(0)
+			if depth == 0 && (t.isPunct(",") || t.isPunct(")")) {
+				break
+			}
+			if t.isPunct("(") {
+				depth++
+			} else if t.isPunct(")") {
+				depth--
+			}
+			p.next()
+		}
+		if p.acceptPunct(")") {
+			break
+		}
+		if err := p.expectPunct(","); err != nil {
+			return nil, err
+		}
+	}
+	if expression {
+		return nil, nil
+	}
+	return columns, nil
+}
+
+// column parses a column definition
+func (p *parser) column(table *Table) error {
+	t := p.next()
+	if t.kind != tokenIdent && t.kind != tokenQuoted {
+		p.pos--
+		return p.errorf("expected a column name")
+	}
+	column := Column{Name: t.text, Nullable: true}
+	if err := p.columnType(&column); err != nil {
+		return fmt.Errorf("column %s: %w", column.Name, err)
+	}
+	switch column.Type {
+	case "serial", "serial4", "bigserial", "serial8", "smallserial", "serial2":
+		column.AutoIncrement = true
+		column.Nullable = false
+	}
+
+	for !p.done() && !p.peek(0).isPunct(",") && !p.peek(0).isPunct(")") {
+		switch {
+		case p.accept("not", "null"):
+			column.Nullable = false
+		case p.accept("null"):
+			column.Nullable = true
+		case p.accept("default"):
+			column.Default = p.defaultValue()
+			if strings.HasPrefix(strings.ToLower(column.Default), "nextval(") {
+				column.AutoIncrement = true
+			}
+		case p.accept("auto_increment"), p.accept("autoincrement"), p.accept("identity"):
+			column.AutoIncrement = true
+			p.skipParens()
+		case p.accept("primary", "key"):
+			table.PrimaryKey = []string{column.Name}
+			column.Nullable = false
+		case p.accept("unique"):
+			p.accept("key")
+			table.Indexes = append(table.Indexes, Index{Columns: []string{column.Name}, Unique: true})
+		case p.accept("comment"):
+			column.Comment = p.next().text
+		case p.accept("generated"):
+			// GENERATED ALWAYS AS IDENTITY, GENERATED BY DEFAULT AS IDENTITY or GENERATED ALWAYS AS (expr)
+			p.accept("always")
+			p.accept("by", "default")
+			p.accept("as")
+			if p.accept("identity") {
+				column.AutoIncrement = true
+				column.Nullable = false
+			} else {
+				column.Computed = true
+			}
+			p.skipParens()
+		case p.peek(0).is("as") && p.peek(1).isPunct("("):
+			p.next()
+			column.Computed = true
+			p.skipParens()
+		case p.accept("references"):
+			p.references()
+		case p.accept("constraint"):
+			p.next()
+		case p.peek(0).isPunct("("):
+			p.skipParens()
+		default:
+			p.next()
+		}
+	}
+	table.Columns = append(table.Columns, column)
+	return nil
+}
+
+// columnType parses the type of a column
+func (p *parser) columnType(column *Column) error {
+	if p.accept("array") {
+		column.Type = "array"
+		column.Array = true
+		return nil
+	}
+	parts, err := p.name()
+	if err != nil {
+		return err
+	}
+	typ := strings.ToLower(strings.Join(parts, "."))
+	// Types of several words
+	switch {
+	case typ == "double" && p.accept("precision"):
+		typ = "double precision"
+	case (typ == "character" || typ == "char" || typ == "bit" || typ == "national") && p.peek(0).is("varying", "character", "char"):
+		for p.peek(0).is("varying", "character", "char") {
+			typ += " " + strings.ToLower(p.next().text)
+		}
+	}
+	if p.acceptPunct("(") {
+		for !p.acceptPunct(")") {
+			if p.done() {
+				return p.errorf("expected %q", ")")
+			}
+			start := p.next()
+			end := start
+			depth := 0
+			for !p.done() && (depth > 0 || !p.peek(0).isPunct(",") && !p.peek(0).isPunct(")")) {
+				if p.peek(0).isPunct("(") {
+					depth++
+				} else if p.peek(0).isPunct(")") {
+					depth--
+				}
+				end = p.next()
+			}
+			arg := start.text
+			if end != start {
+				arg = p.source(start, end)
+			}
+			column.Args = append(column.Args, arg)
+			p.acceptPunct(",")
+		}
+	}
+	if p.accept("with", "time", "zone") {
+		typ += " with time zone"
+	} else if p.accept("without", "time", "zone") {
+		typ += " without time zone"
+	}
+	for {
+		switch {
+		case p.accept("unsigned"):
+			column.Unsigned = true
+		case p.accept("signed"), p.accept("zerofill"):
+		case p.accept("array"):
+			column.Array = true
+		case p.peek(0).isPunct("["):
+			for !p.done() && !p.next().isPunct("]") {
+			}
+			column.Array = true
+		default:
+			column.Type = typ
+			return nil
+		}
+	}
+}
+
+// defaultValue parses the expression of a DEFAULT clause up to the next column constraint
+func (p *parser) defaultValue() string {
+	if p.done() {
+		return ""
+	}
+	first := p.peek(0)
+	last := first
+	depth := 0
+	for !p.done() {
+		t := p.peek(0)
+		if depth == 0 {
+			if t.isPunct(",") || t.isPunct(")") {
+				break
+			}
+			if t != first && t.is("not", "null", "primary", "unique", "check", "references", "constraint",
+				"collate", "comment", "auto_increment", "on", "generated") {
+				break
+			}
+		}
+		if t.isPunct("(") {
+			depth++
+		} else if t.isPunct(")") {
+			depth--
+		}
+		last = p.next()
+	}
+	return p.source(first, last)
+}
+
+// references skips the table, columns and actions of a foreign key
+func (p *parser) references() {
+	if _, err := p.name(); err != nil {
+		return
+	}
+	p.skipParens()
+	for {
+		switch {
+		case p.accept("on"):
+			p.next()
+			if p.accept("set") || p.accept("no") {
+				p.next()
+			} else {
+				p.next()
+			}
+		case p.accept("match"):
+			p.next()
+		case p.accept("deferrable"), p.accept("not", "deferrable"):
+		case p.accept("initially"):
+			p.next()
+		default:
+			return
+		}
+	}
+}
+
+// source returns the source text from the start of the token first to the end of last
+func (p *parser) source(first, last token) string {
+	return p.src[first.start:last.end]
+}
+
+// alter applies CREATE INDEX, COMMENT ON and ALTER TABLE statements to the tables,
+// other statements are ignored
+func (p *parser) alter(find func([]string) *Table) error {
+	switch {
+	case p.accept("create"):
+		unique := p.accept("unique")
+		if !p.accept("index") {
+			return nil
+		}
+		p.accept("concurrently")
+		p.accept("if", "not", "exists")
+		var name string
+		if !p.peek(0).is("on") {
+			parts, err := p.name()
+			if err != nil {
+				return err
+			}
+			name = parts[len(parts)-1]
+		}
+		if !p.accept("on") {
+			return nil
+		}
+		p.accept("only")
+		tableName, err := p.name()
+		if err != nil {
+			return err
+		}
+		if p.accept("using") {
+			p.next()
+		}
+		columns, err := p.indexColumns()
+		if err != nil {
+			return fmt.Errorf("index %s: %w", name, err)
+		}
+		if table := find(tableName); table != nil && columns != nil {
+			table.Indexes = append(table.Indexes, Index{Name: name, Columns: columns, Unique: unique})
+		}
+	case p.accept("comment", "on"):
+		switch {
+		case p.accept("table"):
+			name, err := p.name()
+			if err != nil {
+				return err
+			}
+			if table := find(name); table != nil && p.accept("is") {
+				table.Comment = p.next().text
+			}
+		case p.accept("column"):
+			name, err := p.name()
+			if err != nil || len(name) < 2 {
+				return err
+			}
+			table := find(name[:len(name)-1])
+			if table == nil || !p.accept("is") {
+				return nil
+			}
+			if column := table.Column(name[len(name)-1]); column != nil {
+				column.Comment = p.next().text
+			}
+		}
+	case p.accept("alter", "table"):
+		p.accept("if", "exists")
+		p.accept("only")
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		table := find(name)
+		if table == nil {
+			return nil
+		}
+		for !p.done() {
+			if err := p.alterAction(table); err != nil {
+				return fmt.Errorf("table %s: %w", table.Name, err)
+			}
+			// Skip to the next action
+			for !p.done() && !p.acceptPunct(",") {
+				if p.peek(0).isPunct("(") {
+					p.skipParens()
+				} else {
+					p.next()
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// alterAction applies an action of ALTER TABLE that adds a constraint, an index,
+// a column default or an identity
+func (p *parser) alterAction(table *Table) error {
+	switch {
+	case p.accept("add"):
+		if p.accept("constraint") {
+			parts, err := p.name()
+			if err != nil {
+				return err
+			}
+			return p.constraint(table, parts[len(parts)-1])
+		}
+		if p.peek(0).is("primary", "unique", "key", "index", "fulltext", "spatial", "foreign", "check") {
+			if err := p.constraint(table, ""); err != nil {
+				return err
+			}
+			for _, name := range table.PrimaryKey {
+				if column := table.Column(name); column != nil {
+					column.Nullable = false
+				}
+			}
+			return nil
+		}
+		p.accept("column")
+		p.accept("if", "not", "exists")
+		return p.column(table)
+	case p.accept("alter"):
+		p.accept("column")
+		t := p.next()
+		column := table.Column(t.text)
+		if column == nil {
+			return nil
+		}
+		switch {
+		case p.accept("set", "default"):
+			column.Default = p.defaultValue()
+			if strings.HasPrefix(strings.ToLower(column.Default), "nextval(") {
+				column.AutoIncrement = true
+			}
+		case p.accept("set", "not", "null"):
+			column.Nullable = false
+		case p.accept("add", "generated"):
+			column.AutoIncrement = true
+			column.Nullable = false
+		}
+	}
+	return nil
+}
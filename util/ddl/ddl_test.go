@@ -0,0 +1,192 @@
+package ddl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMySQL(t *testing.T) {
+	src := "/*!40101 SET NAMES utf8mb4 */;\n" +
+		"DROP TABLE IF EXISTS `users`;\n" +
+		"CREATE TABLE `users` (\n" +
+		"  `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n" +
+		"  `email` varchar(255) NOT NULL COMMENT 'Login email',\n" +
+		"  `name` varchar(64) DEFAULT NULL,\n" +
+		"  `active` tinyint(1) NOT NULL DEFAULT '1',\n" +
+		"  `balance` decimal(10,2) NOT NULL DEFAULT '0.00',\n" +
+		"  `profile` json DEFAULT NULL,\n" +
+		"  `full_name` varchar(128) GENERATED ALWAYS AS (concat(`name`,' ',`email`)) VIRTUAL,\n" +
+		"  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,\n" +
+		"  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+		"  `team_id` int DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  UNIQUE KEY `uk_email` (`email`),\n" +
+		"  KEY `idx_name` (`name`(10)),\n" +
+		"  KEY `idx_team_created` (`team_id`,`created_at` DESC),\n" +
+		"  CONSTRAINT `fk_team` FOREIGN KEY (`team_id`) REFERENCES `teams` (`id`) ON DELETE SET NULL\n" +
+		") ENGINE=InnoDB AUTO_INCREMENT=42 DEFAULT CHARSET=utf8mb4 COMMENT='Registered users';\n" +
+		"# trailing comment\n"
+
+	tables, err := Parse(src)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Table{{
+		Name:    "users",
+		Comment: "Registered users",
+		Columns: []Column{
+			{Name: "id", Type: "bigint", Unsigned: true, AutoIncrement: true},
+			{Name: "email", Type: "varchar", Args: []string{"255"}, Comment: "Login email"},
+			{Name: "name", Type: "varchar", Args: []string{"64"}, Nullable: true, Default: "NULL"},
+			{Name: "active", Type: "tinyint", Args: []string{"1"}, Default: "'1'"},
+			{Name: "balance", Type: "decimal", Args: []string{"10", "2"}, Default: "'0.00'"},
+			{Name: "profile", Type: "json", Nullable: true, Default: "NULL"},
+			{Name: "full_name", Type: "varchar", Args: []string{"128"}, Nullable: true, Computed: true},
+			{Name: "created_at", Type: "datetime", Default: "CURRENT_TIMESTAMP"},
+			{Name: "updated_at", Type: "datetime", Default: "CURRENT_TIMESTAMP"},
+			{Name: "team_id", Type: "int", Nullable: true, Default: "NULL"},
+		},
+		PrimaryKey: []string{"id"},
+		Indexes: []Index{
+			{Name: "uk_email", Columns: []string{"email"}, Unique: true},
+			{Name: "idx_name", Columns: []string{"name"}},
+			{Name: "idx_team_created", Columns: []string{"team_id", "created_at"}},
+		},
+	}}, tables)
+}
+
+func TestParsePostgres(t *testing.T) {
+	src := `
+SET statement_timeout = 0;
+CREATE FUNCTION public.touch() RETURNS trigger LANGUAGE plpgsql AS $$
+BEGIN NEW.updated_at = now(); RETURN NEW; END;
+$$;
+
+CREATE TABLE public.accounts (
+    id integer NOT NULL,
+    uuid uuid DEFAULT gen_random_uuid() NOT NULL,
+    "Name" character varying(100) NOT NULL,
+    score double precision,
+    tags text[] DEFAULT '{}'::text[],
+    settings jsonb DEFAULT '{}'::jsonb NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    ref bigint GENERATED BY DEFAULT AS IDENTITY,
+    total numeric GENERATED ALWAYS AS (score * 2) STORED,
+    CONSTRAINT accounts_score_check CHECK ((score >= (0)::double precision))
+);
+
+COMMENT ON TABLE public.accounts IS 'Customer accounts';
+COMMENT ON COLUMN public.accounts."Name" IS 'Display name';
+
+CREATE SEQUENCE public.accounts_id_seq AS integer START WITH 1;
+ALTER TABLE ONLY public.accounts ALTER COLUMN id SET DEFAULT nextval('public.accounts_id_seq'::regclass);
+ALTER TABLE ONLY public.accounts
+    ADD CONSTRAINT accounts_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY public.accounts
+    ADD CONSTRAINT accounts_uuid_key UNIQUE (uuid);
+CREATE INDEX accounts_created_at_idx ON public.accounts USING btree (created_at DESC);
+CREATE INDEX accounts_lower_name_idx ON public.accounts USING btree (lower(("Name")::text));
+CREATE INDEX other_idx ON public.missing USING btree (id);
+`
+
+	tables, err := Parse(src)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Table{{
+		Schema:  "public",
+		Name:    "accounts",
+		Comment: "Customer accounts",
+		Columns: []Column{
+			{Name: "id", Type: "integer", AutoIncrement: true, Default: "nextval('public.accounts_id_seq'::regclass)"},
+			{Name: "uuid", Type: "uuid", Default: "gen_random_uuid()"},
+			{Name: "Name", Type: "character varying", Args: []string{"100"}, Comment: "Display name"},
+			{Name: "score", Type: "double precision", Nullable: true},
+			{Name: "tags", Type: "text", Array: true, Nullable: true, Default: "'{}'::text[]"},
+			{Name: "settings", Type: "jsonb", Default: "'{}'::jsonb"},
+			{Name: "created_at", Type: "timestamp with time zone", Default: "now()"},
+			{Name: "ref", Type: "bigint", AutoIncrement: true},
+			{Name: "total", Type: "numeric", Nullable: true, Computed: true},
+		},
+		PrimaryKey: []string{"id"},
+		Indexes: []Index{
+			{Name: "accounts_uuid_key", Columns: []string{"uuid"}, Unique: true},
+			{Name: "accounts_created_at_idx", Columns: []string{"created_at"}},
+		},
+	}}, tables)
+}
+
+func TestParseInline(t *testing.T) {
+	tables, err := Parse(`
+create table if not exists posts (
+  id serial primary key,
+  slug text unique not null,
+  author_id int references users (id) on delete cascade,
+  body text
+);
+create unique index if not exists posts_author_slug on posts (author_id, slug);
+create table post_copy as select * from posts;
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Table{{
+		Name: "posts",
+		Columns: []Column{
+			{Name: "id", Type: "serial", AutoIncrement: true},
+			{Name: "slug", Type: "text"},
+			{Name: "author_id", Type: "int", Nullable: true},
+			{Name: "body", Type: "text", Nullable: true},
+		},
+		PrimaryKey: []string{"id"},
+		Indexes: []Index{
+			{Columns: []string{"slug"}, Unique: true},
+			{Name: "posts_author_slug", Columns: []string{"author_id", "slug"}, Unique: true},
+		},
+	}}, tables)
+}
+
+func TestParseError(t *testing.T) {
+	testCases := []struct {
+		name        string
+		src         string
+		expectError string
+	}{
+		{"unterminated string", "CREATE TABLE t (a text DEFAULT 'x);", "unterminated '"},
+		{"unterminated comment", "CREATE TABLE t (a int); /* comment", "unterminated comment"},
+		{"missing column type", "CREATE TABLE t (a);", `table t: column a: expected a name at ")"`},
+		{"missing parenthesis", "CREATE TABLE t (a int", `table t: expected "," at end of statement`},
+		{"missing table name", "CREATE TABLE (a int);", `expected a name at "("`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.src)
+			assert.ErrorContains(t, err, tc.expectError)
+		})
+	}
+}
+
+func TestParseColumnType(t *testing.T) {
+	testCases := []struct {
+		typ         string
+		expected    Column
+		expectError string
+	}{
+		{typ: "varchar(255)", expected: Column{Type: "varchar", Args: []string{"255"}}},
+		{typ: "int unsigned", expected: Column{Type: "int", Unsigned: true}},
+		{typ: "decimal(10,2)", expected: Column{Type: "decimal", Args: []string{"10", "2"}}},
+		{typ: "enum('a','b')", expected: Column{Type: "enum", Args: []string{"a", "b"}}},
+		{typ: "timestamp(3) with time zone", expected: Column{Type: "timestamp with time zone", Args: []string{"3"}}},
+		{typ: "character varying(100)[]", expected: Column{Type: "character varying", Args: []string{"100"}, Array: true}},
+		{typ: "public.mood", expected: Column{Type: "public.mood"}},
+		{typ: "", expectError: `invalid column type ""`},
+		{typ: "(1)", expectError: `invalid column type "(1)": expected a name`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.typ, func(t *testing.T) {
+			column, err := ParseColumnType(tc.typ)
+			if tc.expectError != "" {
+				assert.ErrorContains(t, err, tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, column)
+		})
+	}
+}
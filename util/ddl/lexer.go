@@ -0,0 +1,189 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokenKind is the kind of a lexical token
+type tokenKind int
+
+const (
+	tokenIdent  tokenKind = iota // Unquoted identifier or keyword
+	tokenQuoted                  // Quoted identifier, `name` or "name"
+	tokenString                  // String literal, 'text'
+	tokenNumber                  // Numeric literal
+	tokenPunct                   // Punctuation such as ( ) , ; :: [ ]
+)
+
+// token is a lexical token of a SQL statement
+type token struct {
+	kind  tokenKind
+	text  string // Text of the token, the value for quoted identifiers
+	start int    // Offset of the token in the source
+	end   int    // Offset after the token in the source
+}
+
+// is checks if the token is one of the keywords, keywords are case insensitive
+func (t token) is(keywords ...string) bool {
+	if t.kind != tokenIdent {
+		return false
+	}
+	for _, keyword := range keywords {
+		if strings.EqualFold(t.text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPunct checks if the token is the punctuation p
+func (t token) isPunct(p string) bool {
+	return t.kind == tokenPunct && t.text == p
+}
+
+// lex splits SQL source into statements of tokens, skipping comments.
+// Statements are separated by semicolons
+func lex(src string) ([][]token, error) {
+	var statements [][]token
+	var current []token
+	runes := []rune(src)
+	offsets := make([]int, len(runes)+1)
+	for i, offset := 0, 0; i < len(runes); i++ {
+		offsets[i] = offset
+		offset += len(string(runes[i]))
+		offsets[i+1] = offset
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-', r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			continue
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := strings.Index(string(runes[i+2:]), "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i += 2 + len([]rune(string(runes[i+2:])[:end])) + 2
+			continue
+		case r == ';':
+			i++
+			if len(current) > 0 {
+				statements = append(statements, current)
+				current = nil
+			}
+			continue
+		case r == '\'' || r == '`' || r == '"':
+			end, value, err := scanQuoted(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			kind := tokenQuoted
+			if r == '\'' {
+				kind = tokenString
+			}
+			current = append(current, token{kind: kind, text: value, start: offsets[start], end: offsets[end]})
+			i = end
+			continue
+		case r == '$' && i+1 < len(runes) && (runes[i+1] == '$' || isIdentStart(runes[i+1])):
+			// PostgreSQL dollar quoted string, $$text$$ or $tag$text$tag$
+			j := i + 1
+			for j < len(runes) && isIdentPart(runes[j]) && runes[j] != '$' {
+				j++
+			}
+			if j < len(runes) && runes[j] == '$' {
+				tag := string(runes[i : j+1])
+				body := string(runes[j+1:])
+				end := strings.Index(body, tag)
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated dollar quoted string")
+				}
+				i = j + 1 + len([]rune(body[:end])) + len([]rune(tag))
+				current = append(current, token{kind: tokenString, text: body[:end], start: offsets[start], end: offsets[i]})
+				continue
+			}
+		}
+
+		switch {
+		case isIdentStart(r):
+			for i < len(runes) && isIdentPart(runes[i]) {
+				i++
+			}
+			current = append(current, token{kind: tokenIdent, text: string(runes[start:i]), start: offsets[start], end: offsets[i]})
+		case unicode.IsDigit(r) || r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E' ||
+				(runes[i] == '-' || runes[i] == '+') && (runes[i-1] == 'e' || runes[i-1] == 'E')) {
+				i++
+			}
+			current = append(current, token{kind: tokenNumber, text: string(runes[start:i]), start: offsets[start], end: offsets[i]})
+		case r == ':' && i+1 < len(runes) && runes[i+1] == ':':
+			i += 2
+			current = append(current, token{kind: tokenPunct, text: "::", start: offsets[start], end: offsets[i]})
+		default:
+			i++
+			current = append(current, token{kind: tokenPunct, text: string(r), start: offsets[start], end: offsets[i]})
+		}
+	}
+	if len(current) > 0 {
+		statements = append(statements, current)
+	}
+	return statements, nil
+}
+
+// scanQuoted scans a quoted string or identifier starting at runes[i]. A doubled quote
+// stands for the quote itself, in strings a backslash escapes the next character.
+// It returns the offset after the closing quote and the unquoted value
+func scanQuoted(runes []rune, i int) (int, string, error) {
+	quote := runes[i]
+	var value strings.Builder
+	for j := i + 1; j < len(runes); j++ {
+		switch r := runes[j]; {
+		case r == quote && j+1 < len(runes) && runes[j+1] == quote:
+			value.WriteRune(quote)
+			j++
+		case r == quote:
+			return j + 1, value.String(), nil
+		case r == '\\' && quote == '\'' && j+1 < len(runes):
+			j++
+			value.WriteRune(unescape(runes[j]))
+		default:
+			value.WriteRune(r)
+		}
+	}
+	return 0, "", fmt.Errorf("unterminated %c", quote)
+}
+
+// unescape returns the character a backslash escape in a MySQL string stands for
+func unescape(r rune) rune {
+	switch r {
+	case 'n':
+		return '\n'
+	case 't':
+		return '\t'
+	case 'r':
+		return '\r'
+	case '0':
+		return 0
+	default:
+		return r
+	}
+}
+
+// isIdentStart checks if r can start an unquoted identifier
+func isIdentStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
+
+// isIdentPart checks if r can be part of an unquoted identifier
+func isIdentPart(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$'
+}
@@ -0,0 +1,166 @@
+package ddl
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/lewinz/go-gen/util/field"
+)
+
+// Dialect is the SQL dialect of a database
+type Dialect string
+
+const (
+	MySQL    Dialect = "mysql"
+	Postgres Dialect = "postgres"
+)
+
+// GoType returns the Go type of the column. Nullable columns use pointer types,
+// except for types that can hold nil themselves. Arrays are not converted to slices,
+// they are strings in their text form, e.g. {a,b}, which database/sql can scan
+// without driver specific types
+func (c Column) GoType(dialect Dialect) string {
+	typ := c.baseType(dialect)
+	if c.Array {
+		typ = "string"
+	}
+	if c.Nullable {
+		return Nilable(typ)
+	}
+	return typ
+}
+
+// Nilable returns a type that can hold nil, the pointer type unless the type can hold
+// nil itself
+func Nilable(typ string) string {
+	if strings.HasPrefix(typ, "*") || strings.HasPrefix(typ, "[]") || typ == "json.RawMessage" || typ == "interface{}" {
+		return typ
+	}
+	return "*" + typ
+}
+
+// HasDefault checks if the database assigns a default value to the column when an
+// insert leaves it out. Auto-increment and computed columns are never written
+func (c Column) HasDefault() bool {
+	value := strings.ToLower(c.Default)
+	if value == "" || value == "null" || strings.HasPrefix(value, "null::") {
+		return false
+	}
+	return !c.AutoIncrement && !c.Computed
+}
+
+// baseType returns the Go type of a non-null value of the column
+func (c Column) baseType(dialect Dialect) string {
+	switch c.Type {
+	case "bool", "boolean":
+		return "bool"
+	case "tinyint":
+		if len(c.Args) == 1 && c.Args[0] == "1" && dialect == MySQL {
+			return "bool"
+		}
+		return c.intType("int8")
+	case "smallint", "int2", "smallserial", "serial2", "year":
+		return c.intType("int16")
+	case "mediumint", "int", "integer", "int4", "serial", "serial4":
+		return c.intType("int32")
+	case "bigint", "int8", "bigserial", "serial8":
+		return c.intType("int64")
+	case "float4":
+		return "float32"
+	case "real":
+		// MySQL treats REAL as DOUBLE
+		if dialect == MySQL {
+			return "float64"
+		}
+		return "float32"
+	case "float":
+		return c.floatType(dialect)
+	case "double", "double precision", "float8":
+		return "float64"
+	case "decimal", "numeric", "dec", "fixed", "money":
+		// Exact values are kept in their text form, float64 would round them
+		return "string"
+	case "date", "datetime", "timestamp", "timestamptz", "timestamp with time zone", "timestamp without time zone":
+		return "time.Time"
+	case "json", "jsonb":
+		return "json.RawMessage"
+	case "binary", "varbinary", "blob", "tinyblob", "mediumblob", "longblob", "bytea", "bit", "bit varying", "varbit":
+		return "[]byte"
+	default:
+		return "string"
+	}
+}
+
+// floatType returns the Go type of FLOAT, whose precision depends on its arguments.
+// MySQL FLOAT is single precision, PostgreSQL FLOAT is double precision
+func (c Column) floatType(dialect Dialect) string {
+	switch {
+	case len(c.Args) == 1:
+		if precision, err := strconv.Atoi(c.Args[0]); err == nil && precision <= 24 {
+			return "float32"
+		}
+		return "float64"
+	case dialect == MySQL:
+		return "float32"
+	default:
+		return "float64"
+	}
+}
+
+// intType returns the unsigned variant of an integer type for unsigned columns
+func (c Column) intType(typ string) string {
+	if c.Unsigned {
+		return "u" + typ
+	}
+	return typ
+}
+
+// Fields returns the fields of a model for the columns of the table. The column names
+// are the tags of the fields, and single-column indexes mark the fields as indexed or unique.
+// Columns with a default can hold nil, which leaves them to the database on insert. A
+// PostgreSQL primary key with a default, e.g. gen_random_uuid(), is read back instead
+func (t *Table) Fields(dialect Dialect) []field.Field {
+	fields := make([]field.Field, 0, len(t.Columns))
+	for _, c := range t.Columns {
+		f := field.Field{
+			Name:          c.Name,
+			Type:          c.GoType(dialect),
+			Tag:           c.Name,
+			Comment:       firstLine(c.Comment),
+			Optional:      c.Nullable,
+			AutoIncrement: c.AutoIncrement,
+			Computed:      c.Computed,
+		}
+		for _, name := range t.PrimaryKey {
+			if strings.EqualFold(name, c.Name) {
+				f.PrimaryKey = true
+			}
+		}
+		switch {
+		case !c.HasDefault():
+		case f.PrimaryKey:
+			f.AutoIncrement = dialect == Postgres
+		default:
+			f.Type = Nilable(f.Type)
+			f.Default = true
+		}
+		for _, index := range t.Indexes {
+			if len(index.Columns) != 1 || !strings.EqualFold(index.Columns[0], c.Name) {
+				continue
+			}
+			if index.Unique {
+				f.Unique = true
+			} else {
+				f.Index = true
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// firstLine returns the first line of a comment
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(s)
+}
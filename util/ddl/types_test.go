@@ -0,0 +1,97 @@
+package ddl
+
+import (
+	"testing"
+
+	"github.com/lewinz/go-gen/util/field"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColumnGoType(t *testing.T) {
+	testCases := []struct {
+		name     string
+		column   Column
+		dialect  Dialect
+		expected string
+	}{
+		{"mysql bool", Column{Type: "tinyint", Args: []string{"1"}}, MySQL, "bool"},
+		{"postgres boolean", Column{Type: "boolean"}, Postgres, "bool"},
+		{"tinyint", Column{Type: "tinyint", Args: []string{"4"}}, MySQL, "int8"},
+		{"unsigned int", Column{Type: "int", Unsigned: true}, MySQL, "uint32"},
+		{"nullable bigint", Column{Type: "bigint", Nullable: true}, MySQL, "*int64"},
+		{"bigserial", Column{Type: "bigserial"}, Postgres, "int64"},
+		{"mysql float", Column{Type: "float"}, MySQL, "float32"},
+		{"postgres float", Column{Type: "float"}, Postgres, "float64"},
+		{"float precision", Column{Type: "float", Args: []string{"53"}}, MySQL, "float64"},
+		{"mysql real", Column{Type: "real"}, MySQL, "float64"},
+		{"postgres real", Column{Type: "real"}, Postgres, "float32"},
+		{"decimal", Column{Type: "decimal", Args: []string{"10", "2"}}, MySQL, "string"},
+		{"numeric", Column{Type: "numeric", Nullable: true}, Postgres, "*string"},
+		{"varchar", Column{Type: "varchar", Args: []string{"255"}}, MySQL, "string"},
+		{"nullable text", Column{Type: "text", Nullable: true}, Postgres, "*string"},
+		{"timestamptz", Column{Type: "timestamp with time zone"}, Postgres, "time.Time"},
+		{"nullable datetime", Column{Type: "datetime", Nullable: true}, MySQL, "*time.Time"},
+		{"time of day", Column{Type: "time"}, Postgres, "string"},
+		{"nullable json", Column{Type: "jsonb", Nullable: true}, Postgres, "json.RawMessage"},
+		{"nullable blob", Column{Type: "blob", Nullable: true}, MySQL, "[]byte"},
+		{"array", Column{Type: "int", Array: true, Nullable: true}, Postgres, "*string"},
+		{"unknown", Column{Type: "uuid"}, Postgres, "string"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.column.GoType(tc.dialect))
+		})
+	}
+}
+
+func TestTableFields(t *testing.T) {
+	table := &Table{
+		Name: "users",
+		Columns: []Column{
+			{Name: "id", Type: "bigint", AutoIncrement: true},
+			{Name: "email", Type: "varchar", Comment: "Login email\nMust be verified"},
+			{Name: "team_id", Type: "int", Nullable: true},
+			{Name: "full_name", Type: "varchar", Nullable: true, Computed: true},
+			{Name: "active", Type: "tinyint", Args: []string{"1"}, Default: "'1'"},
+			{Name: "nickname", Type: "varchar", Nullable: true, Default: "NULL"},
+		},
+		PrimaryKey: []string{"id"},
+		Indexes: []Index{
+			{Columns: []string{"email"}, Unique: true},
+			{Columns: []string{"team_id"}},
+			{Columns: []string{"team_id", "email"}, Unique: true},
+		},
+	}
+	assert.Equal(t, []field.Field{
+		{Name: "id", Type: "int64", Tag: "id", PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: "string", Tag: "email", Comment: "Login email", Unique: true},
+		{Name: "team_id", Type: "*int32", Tag: "team_id", Optional: true, Index: true},
+		{Name: "full_name", Type: "*string", Tag: "full_name", Optional: true, Computed: true},
+		{Name: "active", Type: "*bool", Tag: "active", Default: true},
+		{Name: "nickname", Type: "*string", Tag: "nickname", Optional: true},
+	}, table.Fields(MySQL))
+
+	// PostgreSQL keys with a default are read back with RETURNING, MySQL keys are written
+	table = &Table{
+		Name:       "tokens",
+		Columns:    []Column{{Name: "id", Type: "uuid", Default: "gen_random_uuid()"}, {Name: "tags", Type: "text", Array: true, Default: "'{}'::text[]"}},
+		PrimaryKey: []string{"id"},
+	}
+	assert.Equal(t, []field.Field{
+		{Name: "id", Type: "string", Tag: "id", PrimaryKey: true, AutoIncrement: true},
+		{Name: "tags", Type: "*string", Tag: "tags", Default: true},
+	}, table.Fields(Postgres))
+	table.Columns[0].Type = "char"
+	assert.Equal(t, field.Field{Name: "id", Type: "string", Tag: "id", PrimaryKey: true}, table.Fields(MySQL)[0])
+}
+
+func TestHasDefault(t *testing.T) {
+	assert.True(t, Column{Default: "0"}.HasDefault())
+	assert.True(t, Column{Default: "CURRENT_TIMESTAMP"}.HasDefault())
+	assert.False(t, Column{}.HasDefault())
+	assert.False(t, Column{Default: "NULL"}.HasDefault())
+	assert.False(t, Column{Default: "NULL::character varying"}.HasDefault())
+	assert.False(t, Column{Default: "nextval('t_id_seq'::regclass)", AutoIncrement: true}.HasDefault())
+	assert.False(t, Column{Default: "0", Computed: true}.HasDefault())
+}
@@ -0,0 +1,227 @@
+package field
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+
+	"github.com/lewinz/go-gen/util/naming"
+	"gopkg.in/yaml.v3"
+)
+
+// Field is a field of a generated model
+type Field struct {
+	Name     string  `yaml:"name"`     // Field name in any naming style, e.g. created_by or createdBy
+	Type     string  `yaml:"type"`     // Go type, e.g. string, []string or time.Time
+	Tag      string  `yaml:"tag"`      // bson/json key, defaults to the camel case name
+	Comment  string  `yaml:"comment"`  // Doc comment of the field
+	Optional bool    `yaml:"optional"` // Omitted from documents when empty
+	Index    bool    `yaml:"index"`    // Indexed
+	Unique   bool    `yaml:"unique"`   // Unique, implies indexed
+	Fields   []Field `yaml:"fields"`   // Fields of a nested struct, whose name is the element type of Type
+
+	PrimaryKey    bool `yaml:"primaryKey"`    // Primary key column of a SQL table
+	AutoIncrement bool `yaml:"autoIncrement"` // Value assigned by the database on insert
+	Computed      bool `yaml:"computed"`      // Generated column, never written
+	Default       bool `yaml:"default"`       // Column with a default, left out of inserts while nil
+}
+
+// Struct is a struct type declared for the nested fields of a model
+type Struct struct {
+	Name   string  // Go type name
+	Fields []Field // Fields of the struct
+}
+
+// Schema is a YAML file describing the fields of a model
+type Schema struct {
+	Fields []Field `yaml:"fields"`
+}
+
+// Parse parses a field given on the command line as name:type[:flags], where flags
+// is a comma or colon separated list of optional, index and unique,
+// e.g. age:int:index or email:string:unique,optional
+func Parse(spec string) (Field, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 {
+		return Field{}, fmt.Errorf("invalid field %q, expected name:type[:flags]", spec)
+	}
+	f := Field{Name: parts[0], Type: parts[1]}
+	for _, part := range parts[2:] {
+		for _, flag := range strings.Split(part, ",") {
+			switch strings.TrimSpace(flag) {
+			case "optional":
+				f.Optional = true
+			case "index":
+				f.Index = true
+			case "unique":
+				f.Unique = true
+			default:
+				return Field{}, fmt.Errorf("invalid field %q: unknown flag %q", spec, flag)
+			}
+		}
+	}
+	if err := f.Validate(); err != nil {
+		return Field{}, err
+	}
+	return f, nil
+}
+
+// LoadSchema reads the fields of a YAML schema file
+func LoadSchema(path string) ([]Field, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read schema: %w", err)
+	}
+	var schema Schema
+	if err := yaml.Unmarshal(content, &schema); err != nil {
+		return nil, fmt.Errorf("parse schema %s: %w", path, err)
+	}
+	if err := Validate(schema.Fields); err != nil {
+		return nil, fmt.Errorf("schema %s: %w", path, err)
+	}
+	return schema.Fields, nil
+}
+
+// Validate checks the fields and that no two of them have the same Go name
+func Validate(fields []Field) error {
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if err := f.Validate(); err != nil {
+			return err
+		}
+		if seen[f.NamePascal()] {
+			return fmt.Errorf("field %s is defined twice", f.NamePascal())
+		}
+		seen[f.NamePascal()] = true
+	}
+	return nil
+}
+
+// Validate checks that the field has a valid name and Go type, and so do its nested fields
+func (f Field) Validate() error {
+	name := f.NamePascal()
+	if name == "" || !token.IsIdentifier(name) || !ast.IsExported(name) {
+		return fmt.Errorf("invalid field name %q", f.Name)
+	}
+	expr, err := parser.ParseExpr(f.Type)
+	if err != nil || !isType(expr) {
+		return fmt.Errorf("field %s: invalid type %q", f.Name, f.Type)
+	}
+	if len(f.Fields) > 0 {
+		if !token.IsIdentifier(f.StructName()) {
+			return fmt.Errorf("field %s: type %q of nested fields is not a struct name", f.Name, f.Type)
+		}
+		if err := Validate(f.Fields); err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// StructName returns the element type of the field, e.g. Address for []*Address
+// or map[string]Address
+func (f Field) StructName() string {
+	name := f.Type
+	for {
+		trimmed := name
+		for _, prefix := range []string{"[]", "*", "map[string]"} {
+			trimmed = strings.TrimPrefix(trimmed, prefix)
+		}
+		if trimmed == name {
+			return name
+		}
+		name = trimmed
+	}
+}
+
+// Structs returns the struct types declared by nested fields, depth first.
+// A struct used by several fields is returned once
+func Structs(fields []Field) []Struct {
+	var structs []Struct
+	seen := make(map[string]bool)
+	var walk func(fields []Field)
+	walk = func(fields []Field) {
+		for _, f := range fields {
+			if len(f.Fields) == 0 || seen[f.StructName()] {
+				continue
+			}
+			seen[f.StructName()] = true
+			structs = append(structs, Struct{Name: f.StructName(), Fields: f.Fields})
+			walk(f.Fields)
+		}
+	}
+	walk(fields)
+	return structs
+}
+
+// NameSnake returns the name of the field in snake case, e.g. created_by
+func (f Field) NameSnake() string {
+	return naming.NewConverter(naming.StyleSnake).Convert(f.Name)
+}
+
+// NameCamel returns the name of the field in camel case, e.g. createdBy
+func (f Field) NameCamel() string {
+	return naming.NewConverter(naming.StyleCamel).Convert(f.Name)
+}
+
+// NamePascal returns the name of the field in Pascal case, e.g. CreatedBy
+func (f Field) NamePascal() string {
+	return naming.NewConverter(naming.StylePascal).Convert(f.Name)
+}
+
+// NameKebab returns the name of the field in kebab case, e.g. created-by
+func (f Field) NameKebab() string {
+	return naming.NewConverter(naming.StyleKebab).Convert(f.Name)
+}
+
+// TagName returns the bson/json key of the field
+func (f Field) TagName() string {
+	if f.Tag != "" {
+		return f.Tag
+	}
+	return f.NameCamel()
+}
+
+// Tags returns the struct tags of the field, e.g. bson:"age,omitempty" json:"age,omitempty"
+func (f Field) Tags() string {
+	name := f.TagName()
+	if f.Optional {
+		name += ",omitempty"
+	}
+	return fmt.Sprintf(`bson:"%s" json:"%s"`, name, name)
+}
+
+// Indexed checks if the field has an index
+func (f Field) Indexed() bool {
+	return f.Index || f.Unique
+}
+
+// Writable checks if the field is written by inserts and updates, values of
+// auto-increment and computed fields are assigned by the database
+func (f Field) Writable() bool {
+	return !f.AutoIncrement && !f.Computed
+}
+
+// isType checks if expr is a type expression
+func isType(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return true
+	case *ast.SelectorExpr:
+		_, ok := t.X.(*ast.Ident)
+		return ok
+	case *ast.StarExpr:
+		return isType(t.X)
+	case *ast.ArrayType:
+		return isType(t.Elt)
+	case *ast.MapType:
+		return isType(t.Key) && isType(t.Value)
+	case *ast.InterfaceType, *ast.StructType:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,106 @@
+package field
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name        string
+		spec        string
+		expected    Field
+		expectError string
+	}{
+		{"simple", "name:string", Field{Name: "name", Type: "string"}, ""},
+		{"slice", "tags:[]string", Field{Name: "tags", Type: "[]string"}, ""},
+		{"qualified type", "birthday:*time.Time", Field{Name: "birthday", Type: "*time.Time"}, ""},
+		{"map", "labels:map[string]string", Field{Name: "labels", Type: "map[string]string"}, ""},
+		{"flag", "age:int:index", Field{Name: "age", Type: "int", Index: true}, ""},
+		{"comma separated flags", "email:string:unique,optional", Field{Name: "email", Type: "string", Unique: true, Optional: true}, ""},
+		{"colon separated flags", "email:string:unique:optional", Field{Name: "email", Type: "string", Unique: true, Optional: true}, ""},
+		{"missing type", "name", Field{}, "expected name:type[:flags]"},
+		{"unknown flag", "age:int:sorted", Field{}, `unknown flag "sorted"`},
+		{"invalid name", "1st:int", Field{}, "invalid field name"},
+		{"empty name", ":int", Field{}, "invalid field name"},
+		{"invalid type", "age:1", Field{}, "invalid type"},
+		{"expression type", "age:f()", Field{}, "invalid type"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := Parse(tc.spec)
+			if tc.expectError != "" {
+				assert.ErrorContains(t, err, tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, f)
+		})
+	}
+}
+
+func TestFieldNames(t *testing.T) {
+	f := Field{Name: "created_by", Type: "string"}
+	assert.Equal(t, "created_by", f.NameSnake())
+	assert.Equal(t, "createdBy", f.NameCamel())
+	assert.Equal(t, "CreatedBy", f.NamePascal())
+	assert.Equal(t, "created-by", f.NameKebab())
+	assert.Equal(t, "createdBy", f.TagName())
+	assert.Equal(t, `bson:"createdBy" json:"createdBy"`, f.Tags())
+
+	f = Field{Name: "owner", Type: "string", Tag: "owner_id", Optional: true}
+	assert.Equal(t, "owner_id", f.TagName())
+	assert.Equal(t, `bson:"owner_id,omitempty" json:"owner_id,omitempty"`, f.Tags())
+}
+
+func TestIndexed(t *testing.T) {
+	assert.False(t, Field{}.Indexed())
+	assert.True(t, Field{Index: true}.Indexed())
+	assert.True(t, Field{Unique: true}.Indexed())
+}
+
+func TestWritable(t *testing.T) {
+	assert.True(t, Field{PrimaryKey: true}.Writable())
+	assert.False(t, Field{AutoIncrement: true}.Writable())
+	assert.False(t, Field{Computed: true}.Writable())
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate([]Field{{Name: "name", Type: "string"}, {Name: "age", Type: "int"}}))
+	assert.ErrorContains(t, Validate([]Field{{Name: "user_name", Type: "string"}, {Name: "userName", Type: "string"}}), "field UserName is defined twice")
+	assert.ErrorContains(t, Validate([]Field{{Name: "age"}}), "invalid type")
+}
+
+func TestLoadSchema(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "user.yaml")
+	err := os.WriteFile(path, []byte(`fields:
+  - name: email
+    type: string
+    unique: true
+    comment: Login email
+  - name: tags
+    type: "[]string"
+    optional: true
+`), 0644)
+	assert.NoError(t, err)
+
+	fields, err := LoadSchema(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []Field{
+		{Name: "email", Type: "string", Unique: true, Comment: "Login email"},
+		{Name: "tags", Type: "[]string", Optional: true},
+	}, fields)
+
+	err = os.WriteFile(path, []byte("fields:\n  - name: email\n"), 0644)
+	assert.NoError(t, err)
+	_, err = LoadSchema(path)
+	assert.ErrorContains(t, err, "invalid type")
+
+	_, err = LoadSchema(filepath.Join(dir, "missing.yaml"))
+	assert.ErrorContains(t, err, "read schema")
+}
@@ -0,0 +1,313 @@
+package field
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lewinz/go-gen/util/naming"
+)
+
+// extendedTypes are the Go types of MongoDB extended JSON values such as
+// {"$oid": "..."}, as written by mongoexport
+var extendedTypes = map[string]string{
+	"$oid":           "primitive.ObjectID",
+	"$date":          "time.Time",
+	"$numberInt":     "int",
+	"$numberLong":    "int64",
+	"$numberDouble":  "float64",
+	"$numberDecimal": "primitive.Decimal128",
+	"$binary":        "[]byte",
+	"$timestamp":     "primitive.Timestamp",
+}
+
+// object is a JSON object that keeps the order of its keys
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// get returns the value of a key
+func (o *object) get(key string) interface{} {
+	return o.values[key]
+}
+
+// shape is what the samples of a JSON value have in common
+type shape struct {
+	types   []string     // Go types of the scalar values, in the order they were seen
+	object  *objectShape // Keys of the object values
+	elem    *shape       // Elements of the array values
+	array   bool         // Arrays were seen
+	null    bool         // Null was seen
+	present int          // Number of objects the value was seen in
+}
+
+// objectShape is what the samples of a JSON object have in common
+type objectShape struct {
+	keys  []string          // Keys in the order they were first seen
+	props map[string]*shape // Values of the keys
+	count int               // Number of objects
+}
+
+// InferJSON infers the fields of a model from sample JSON documents. The content is a
+// single document, an array of documents or a sequence of documents, such as the output
+// of mongoexport. Keys missing from some documents or null in some are optional, keys
+// with values of different types are interface{}. Nested objects become structs named
+// after typeName and their key, e.g. UserAddress
+func InferJSON(content []byte, typeName string) ([]Field, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	root := &objectShape{props: make(map[string]*shape)}
+	for {
+		value, err := decodeValue(dec)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse sample: %w", err)
+		}
+		docs := []interface{}{value}
+		if list, ok := value.([]interface{}); ok {
+			docs = list
+		}
+		for _, doc := range docs {
+			obj, ok := doc.(*object)
+			if !ok {
+				return nil, fmt.Errorf("sample document is not an object")
+			}
+			root.observe(obj)
+		}
+	}
+	if root.count == 0 {
+		return nil, fmt.Errorf("no sample documents")
+	}
+
+	fields := root.fields(pascal(typeName))
+	if err := Validate(fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// decodeValue decodes the next JSON value, keeping the order of object keys
+func decodeValue(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+	switch delim {
+	case '{':
+		obj := &object{values: make(map[string]interface{})}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			name := key.(string)
+			if _, ok := obj.values[name]; !ok {
+				obj.keys = append(obj.keys, name)
+			}
+			obj.values[name] = value
+		}
+		_, err = dec.Token()
+		return obj, unexpectedEOF(err)
+	case '[':
+		list := []interface{}{}
+		for dec.More() {
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			list = append(list, value)
+		}
+		_, err = dec.Token()
+		return list, unexpectedEOF(err)
+	default:
+		return nil, fmt.Errorf("unexpected %v", delim)
+	}
+}
+
+// unexpectedEOF reports the end of the input inside a value as an error
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// observe adds a JSON object to the samples
+func (o *objectShape) observe(obj *object) {
+	o.count++
+	for _, key := range obj.keys {
+		prop, ok := o.props[key]
+		if !ok {
+			prop = &shape{}
+			o.props[key] = prop
+			o.keys = append(o.keys, key)
+		}
+		prop.present++
+		prop.observe(obj.get(key))
+	}
+}
+
+// observe adds a JSON value to the samples
+func (s *shape) observe(value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		s.null = true
+	case bool:
+		s.addType("bool")
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			s.addType("time.Time")
+		} else {
+			s.addType("string")
+		}
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			s.addType("int")
+		} else {
+			s.addType("float64")
+		}
+	case []interface{}:
+		s.array = true
+		if s.elem == nil {
+			s.elem = &shape{}
+		}
+		for _, item := range v {
+			s.elem.present++
+			s.elem.observe(item)
+		}
+	case *object:
+		if len(v.keys) > 0 {
+			if typ, ok := extendedTypes[v.keys[0]]; ok {
+				s.addType(typ)
+				return
+			}
+		}
+		if s.object == nil {
+			s.object = &objectShape{props: make(map[string]*shape)}
+		}
+		s.object.observe(v)
+	}
+}
+
+// addType records the Go type of a scalar value
+func (s *shape) addType(typ string) {
+	for _, t := range s.types {
+		if t == typ {
+			return
+		}
+	}
+	s.types = append(s.types, typ)
+}
+
+// fields returns the fields of the object, structName prefixes the names of nested structs
+func (o *objectShape) fields(structName string) []Field {
+	var fields []Field
+	for _, key := range o.keys {
+		name := goName(key)
+		if name == "" {
+			continue
+		}
+		prop := o.props[key]
+		f := Field{Name: name, Optional: prop.present < o.count || prop.null}
+		f.Type, f.Fields = prop.goType(structName + pascal(name))
+		if key != f.NameCamel() {
+			f.Tag = key
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// goType returns the Go type of the samples and the fields of the struct named
+// structName when the samples are objects
+func (s *shape) goType(structName string) (string, []Field) {
+	kinds := 0
+	for _, seen := range []bool{len(s.types) > 0, s.object != nil, s.array} {
+		if seen {
+			kinds++
+		}
+	}
+	switch {
+	case kinds != 1:
+		return "interface{}", nil
+	case s.object != nil:
+		if len(s.object.keys) == 0 {
+			return "map[string]interface{}", nil
+		}
+		return structName, s.object.fields(structName)
+	case s.array:
+		if s.elem.present == 0 {
+			return "[]interface{}", nil
+		}
+		elem, fields := s.elem.goType(naming.Singularize(structName))
+		return "[]" + elem, fields
+	default:
+		return mergeTypes(s.types), nil
+	}
+}
+
+// mergeTypes returns a Go type that holds all the scalar types,
+// e.g. float64 for int and float64
+func mergeTypes(types []string) string {
+	typ := types[0]
+	for _, t := range types[1:] {
+		switch {
+		case t == typ:
+		case isNumber(t) && isNumber(typ):
+			if t == "float64" || typ == "float64" {
+				typ = "float64"
+			} else {
+				typ = "int64"
+			}
+		case t == "string" && typ == "time.Time", t == "time.Time" && typ == "string":
+			typ = "string"
+		default:
+			return "interface{}"
+		}
+	}
+	return typ
+}
+
+// isNumber checks if a Go type is a number type of JSON samples
+func isNumber(typ string) bool {
+	return typ == "int" || typ == "int64" || typ == "float64"
+}
+
+// goName turns a JSON key into a field name, replacing characters that cannot be
+// used in Go identifiers. It returns an empty name if nothing is left
+func goName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, key)
+	if strings.Trim(name, "_") == "" {
+		return ""
+	}
+	if first := []rune(strings.TrimLeft(name, "_"))[0]; unicode.IsDigit(first) {
+		name = "field_" + name
+	}
+	return name
+}
+
+// pascal converts a name to Pascal case
+func pascal(name string) string {
+	return naming.NewConverter(naming.StylePascal).Convert(name)
+}
@@ -0,0 +1,118 @@
+package field
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferJSON(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		expected    []Field
+		expectError string
+	}{
+		{
+			name:    "scalars",
+			content: `{"name": "Ann", "age": 30, "score": 9.5, "active": true, "birthday": "1990-01-02T03:04:05Z"}`,
+			expected: []Field{
+				{Name: "name", Type: "string"},
+				{Name: "age", Type: "int"},
+				{Name: "score", Type: "float64"},
+				{Name: "active", Type: "bool"},
+				{Name: "birthday", Type: "time.Time"},
+			},
+		},
+		{
+			name: "extended json",
+			content: `{"_id": {"$oid": "5f1d7a1b2c3d4e5f6a7b8c9d"}, "createdAt": {"$date": "2020-07-26T10:00:00Z"},
+				"views": {"$numberLong": "12"}, "price": {"$numberDecimal": "9.99"}}`,
+			expected: []Field{
+				{Name: "_id", Type: "primitive.ObjectID", Tag: "_id"},
+				{Name: "createdAt", Type: "time.Time"},
+				{Name: "views", Type: "int64"},
+				{Name: "price", Type: "primitive.Decimal128"},
+			},
+		},
+		{
+			name: "merged documents",
+			content: `{"name": "Ann", "age": 30, "nickname": null, "extra": 1}
+{"name": "Bob", "age": 31.5, "nickname": "bob", "extra": "one"}
+{"name": "Eve", "age": 29}`,
+			expected: []Field{
+				{Name: "name", Type: "string"},
+				{Name: "age", Type: "float64"},
+				{Name: "nickname", Type: "string", Optional: true},
+				{Name: "extra", Type: "interface{}", Optional: true},
+			},
+		},
+		{
+			name:    "array of documents",
+			content: `[{"name": "Ann"}, {"name": "Bob", "email": "bob@example.com"}]`,
+			expected: []Field{
+				{Name: "name", Type: "string"},
+				{Name: "email", Type: "string", Optional: true},
+			},
+		},
+		{
+			name: "nested objects and arrays",
+			content: `{"address": {"city": "Paris", "zip_code": "75001"}, "tags": ["a", "b"], "scores": [],
+				"addresses": [{"city": "Rome"}, {"city": "Oslo", "primary": true}], "meta": {}}`,
+			expected: []Field{
+				{Name: "address", Type: "UserAddress", Fields: []Field{
+					{Name: "city", Type: "string"},
+					{Name: "zip_code", Type: "string", Tag: "zip_code"},
+				}},
+				{Name: "tags", Type: "[]string"},
+				{Name: "scores", Type: "[]interface{}"},
+				{Name: "addresses", Type: "[]UserAddress", Fields: []Field{
+					{Name: "city", Type: "string"},
+					{Name: "primary", Type: "bool", Optional: true},
+				}},
+				{Name: "meta", Type: "map[string]interface{}"},
+			},
+		},
+		{
+			name:    "invalid names",
+			content: `{"first-name": "Ann", "2fa": true, "$": 1}`,
+			expected: []Field{
+				{Name: "first_name", Type: "string", Tag: "first-name"},
+				{Name: "field_2fa", Type: "bool", Tag: "2fa"},
+			},
+		},
+		{name: "not an object", content: `[1, 2]`, expectError: "sample document is not an object"},
+		{name: "empty", content: ``, expectError: "no sample documents"},
+		{name: "truncated", content: `{"name": "Ann"`, expectError: "parse sample"},
+		{name: "same go name", content: `{"userName": "a", "user_name": "b"}`, expectError: "field UserName is defined twice"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fields, err := InferJSON([]byte(tc.content), "user")
+			if tc.expectError != "" {
+				assert.ErrorContains(t, err, tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, fields)
+		})
+	}
+}
+
+func TestStructs(t *testing.T) {
+	address := []Field{{Name: "city", Type: "string"}, {Name: "geo", Type: "*Geo", Fields: []Field{{Name: "lat", Type: "float64"}}}}
+	fields := []Field{
+		{Name: "name", Type: "string"},
+		{Name: "home", Type: "Address", Fields: address},
+		{Name: "addresses", Type: "[]Address", Fields: address},
+		{Name: "labels", Type: "map[string]Label", Fields: []Field{{Name: "text", Type: "string"}}},
+	}
+	assert.Equal(t, []Struct{
+		{Name: "Address", Fields: address},
+		{Name: "Geo", Fields: address[1].Fields},
+		{Name: "Label", Fields: []Field{{Name: "text", Type: "string"}}},
+	}, Structs(fields))
+
+	assert.ErrorContains(t, Validate([]Field{{Name: "home", Type: "map[int]Address", Fields: address}}), "is not a struct name")
+}
@@ -0,0 +1,214 @@
+package field
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lewinz/go-gen/util/naming"
+)
+
+// schemaTypes are the Go types of JSON Schema types and MongoDB BSON types
+var schemaTypes = map[string]string{
+	"string":     "string",
+	"integer":    "int",
+	"int":        "int",
+	"long":       "int64",
+	"number":     "float64",
+	"double":     "float64",
+	"decimal":    "primitive.Decimal128",
+	"boolean":    "bool",
+	"bool":       "bool",
+	"date":       "time.Time",
+	"timestamp":  "primitive.Timestamp",
+	"objectId":   "primitive.ObjectID",
+	"binData":    "[]byte",
+	"regex":      "primitive.Regex",
+	"javascript": "primitive.JavaScript",
+}
+
+// schemaReader converts a JSON Schema into fields
+type schemaReader struct {
+	root      *object         // Root schema, local $ref pointers are resolved against it
+	resolving map[string]bool // $ref pointers being resolved, to stop at recursive definitions
+}
+
+// InferJSONSchema reads the fields of a model from a JSON Schema. MongoDB collection
+// validators are accepted as well: the schema may be wrapped in $jsonSchema and use
+// bsonType instead of type. Properties that are not required or may be null are optional.
+// Nested objects become structs named after typeName and their property, e.g. UserAddress
+func InferJSONSchema(content []byte, typeName string) ([]Field, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	value, err := decodeValue(dec)
+	if err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	root, ok := value.(*object)
+	if !ok {
+		return nil, fmt.Errorf("schema is not an object")
+	}
+	// Collection options as returned by db.getCollectionInfos() or a bare validator
+	if validator, ok := root.get("validator").(*object); ok {
+		root = validator
+	}
+	if schema, ok := root.get("$jsonSchema").(*object); ok {
+		root = schema
+	}
+	if _, ok := root.get("properties").(*object); !ok {
+		return nil, fmt.Errorf("schema has no properties")
+	}
+
+	r := &schemaReader{root: root, resolving: make(map[string]bool)}
+	fields := r.fields(root, pascal(typeName))
+	if err := Validate(fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// fields returns the fields of the properties of an object schema,
+// structName prefixes the names of nested structs
+func (r *schemaReader) fields(schema *object, structName string) []Field {
+	props, _ := schema.get("properties").(*object)
+	if props == nil {
+		return nil
+	}
+	required := make(map[string]bool)
+	if list, ok := schema.get("required").([]interface{}); ok {
+		for _, key := range list {
+			if key, ok := key.(string); ok {
+				required[key] = true
+			}
+		}
+	}
+
+	var fields []Field
+	for _, key := range props.keys {
+		name := goName(key)
+		prop, ok := props.get(key).(*object)
+		if name == "" || !ok {
+			continue
+		}
+		f := Field{Name: name, Optional: !required[key]}
+		if description, ok := prop.get("description").(string); ok {
+			f.Comment, _, _ = strings.Cut(strings.TrimSpace(description), "\n")
+		}
+		var nullable bool
+		f.Type, f.Fields, nullable = r.goType(prop, structName+pascal(name))
+		f.Optional = f.Optional || nullable
+		if key != f.NameCamel() {
+			f.Tag = key
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// goType returns the Go type of a schema, the fields of the struct named structName
+// when the schema is an object with properties, and whether the value may be null
+func (r *schemaReader) goType(schema *object, structName string) (string, []Field, bool) {
+	if ref, ok := schema.get("$ref").(string); ok {
+		if r.resolving[ref] {
+			return "interface{}", nil, false
+		}
+		target := r.resolve(ref)
+		if target == nil {
+			return "interface{}", nil, false
+		}
+		r.resolving[ref] = true
+		defer delete(r.resolving, ref)
+		schema = target
+	}
+
+	types, nullable := r.types(schema)
+	if len(types) != 1 {
+		return "interface{}", nil, nullable
+	}
+	switch typ := types[0]; typ {
+	case "object":
+		if props, ok := schema.get("properties").(*object); ok && len(props.keys) > 0 {
+			return structName, r.fields(schema, structName), nullable
+		}
+		if values, ok := schema.get("additionalProperties").(*object); ok {
+			elem, fields, _ := r.goType(values, structName)
+			return "map[string]" + elem, fields, nullable
+		}
+		return "map[string]interface{}", nil, nullable
+	case "array":
+		items, ok := schema.get("items").(*object)
+		if !ok {
+			return "[]interface{}", nil, nullable
+		}
+		elem, fields, _ := r.goType(items, naming.Singularize(structName))
+		return "[]" + elem, fields, nullable
+	case "string":
+		if format, _ := schema.get("format").(string); format == "date-time" || format == "date" {
+			return "time.Time", nil, nullable
+		}
+		return "string", nil, nullable
+	default:
+		if goType, ok := schemaTypes[typ]; ok {
+			return goType, nil, nullable
+		}
+		return "interface{}", nil, nullable
+	}
+}
+
+// types returns the types of a schema other than null, and whether null is one of them.
+// Schemas without a type are objects if they have properties and arrays if they have items
+func (r *schemaReader) types(schema *object) ([]string, bool) {
+	value := schema.get("bsonType")
+	if value == nil {
+		value = schema.get("type")
+	}
+	var types []string
+	switch v := value.(type) {
+	case string:
+		types = []string{v}
+	case []interface{}:
+		for _, t := range v {
+			if t, ok := t.(string); ok {
+				types = append(types, t)
+			}
+		}
+	case nil:
+		if _, ok := schema.get("properties").(*object); ok {
+			return []string{"object"}, false
+		}
+		if _, ok := schema.get("items").(*object); ok {
+			return []string{"array"}, false
+		}
+	}
+
+	var nullable bool
+	var result []string
+	for _, t := range types {
+		if t == "null" {
+			nullable = true
+		} else {
+			result = append(result, t)
+		}
+	}
+	return result, nullable
+}
+
+// resolve returns the schema a local $ref pointer such as #/definitions/Address
+// or #/$defs/Address points to, nil if it cannot be resolved
+func (r *schemaReader) resolve(ref string) *object {
+	pointer, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil
+	}
+	node := r.root
+	for _, part := range strings.Split(pointer, "/") {
+		part = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+		next, ok := node.get(part).(*object)
+		if !ok {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
@@ -0,0 +1,116 @@
+package field
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferJSONSchema(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		expected    []Field
+		expectError string
+	}{
+		{
+			name: "json schema",
+			content: `{
+				"type": "object",
+				"required": ["name", "email"],
+				"properties": {
+					"name": {"type": "string", "description": "Display name\nShown in the UI"},
+					"email": {"type": "string"},
+					"age": {"type": "integer"},
+					"score": {"type": ["number", "null"]},
+					"birthday": {"type": "string", "format": "date-time"},
+					"tags": {"type": "array", "items": {"type": "string"}},
+					"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+					"any": {}
+				}
+			}`,
+			expected: []Field{
+				{Name: "name", Type: "string", Comment: "Display name"},
+				{Name: "email", Type: "string"},
+				{Name: "age", Type: "int", Optional: true},
+				{Name: "score", Type: "float64", Optional: true},
+				{Name: "birthday", Type: "time.Time", Optional: true},
+				{Name: "tags", Type: "[]string", Optional: true},
+				{Name: "labels", Type: "map[string]string", Optional: true},
+				{Name: "any", Type: "interface{}", Optional: true},
+			},
+		},
+		{
+			name: "mongodb validator",
+			content: `{"validator": {"$jsonSchema": {
+				"bsonType": "object",
+				"required": ["_id", "owner_id", "created"],
+				"properties": {
+					"_id": {"bsonType": "objectId"},
+					"owner_id": {"bsonType": "objectId"},
+					"created": {"bsonType": "date"},
+					"views": {"bsonType": "long"},
+					"price": {"bsonType": "decimal"},
+					"address": {
+						"bsonType": "object",
+						"required": ["city"],
+						"properties": {"city": {"bsonType": "string"}, "zip": {"bsonType": ["string", "null"]}}
+					},
+					"items": {"bsonType": "array", "items": {"bsonType": "object", "properties": {"sku": {"bsonType": "string"}}}}
+				}
+			}}}`,
+			expected: []Field{
+				{Name: "_id", Type: "primitive.ObjectID", Tag: "_id"},
+				{Name: "owner_id", Type: "primitive.ObjectID", Tag: "owner_id"},
+				{Name: "created", Type: "time.Time"},
+				{Name: "views", Type: "int64", Optional: true},
+				{Name: "price", Type: "primitive.Decimal128", Optional: true},
+				{Name: "address", Type: "OrderAddress", Optional: true, Fields: []Field{
+					{Name: "city", Type: "string"},
+					{Name: "zip", Type: "string", Optional: true},
+				}},
+				{Name: "items", Type: "[]OrderItem", Optional: true, Fields: []Field{
+					{Name: "sku", Type: "string", Optional: true},
+				}},
+			},
+		},
+		{
+			name: "references",
+			content: `{
+				"properties": {
+					"home": {"$ref": "#/definitions/address"},
+					"parent": {"$ref": "#/definitions/node"},
+					"missing": {"$ref": "#/definitions/missing"}
+				},
+				"definitions": {
+					"address": {"type": "object", "properties": {"city": {"type": "string"}}},
+					"node": {"type": "object", "properties": {"parent": {"$ref": "#/definitions/node"}}}
+				}
+			}`,
+			expected: []Field{
+				{Name: "home", Type: "OrderHome", Optional: true, Fields: []Field{
+					{Name: "city", Type: "string", Optional: true},
+				}},
+				{Name: "parent", Type: "OrderParent", Optional: true, Fields: []Field{
+					{Name: "parent", Type: "interface{}", Optional: true},
+				}},
+				{Name: "missing", Type: "interface{}", Optional: true},
+			},
+		},
+		{name: "no properties", content: `{"type": "object"}`, expectError: "schema has no properties"},
+		{name: "not an object", content: `[]`, expectError: "schema is not an object"},
+		{name: "invalid json", content: `{"properties": `, expectError: "parse schema"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fields, err := InferJSONSchema([]byte(tc.content), "order")
+			if tc.expectError != "" {
+				assert.ErrorContains(t, err, tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, fields)
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Error describes Go source that could not be parsed
+type Error struct {
+	Line int    // Line number in the generated source
+	Msg  string // Parser error message
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// importSpec is a single import of a Go file
+type importSpec struct {
+	name string // Explicit import name, empty if none
+	path string // Import path
+}
+
+// Source formats Go source code like gofmt and fixes up its imports:
+// unused imports are removed and missing standard library imports are added
+func Source(src []byte) ([]byte, error) {
+	fixed, err := fixImports(src)
+	if err != nil {
+		return nil, err
+	}
+	out, err := format.Source(fixed)
+	if err != nil {
+		return nil, toError(err)
+	}
+	return out, nil
+}
+
+// fixImports rewrites the import declarations of src when they need to change
+func fixImports(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, toError(err)
+	}
+
+	used := usedPackages(file)
+
+	// Drop unused imports
+	var imports []importSpec
+	imported := make(map[string]bool)
+	changed := false
+	for _, spec := range file.Imports {
+		imp := importSpec{path: strings.Trim(spec.Path.Value, "`\"")}
+		if spec.Name != nil {
+			imp.name = spec.Name.Name
+		}
+		name, certain := packageName(imp)
+		if certain && used[name] == nil && imp.name != "_" && imp.name != "." {
+			changed = true
+			continue
+		}
+		imported[name] = true
+		if !certain {
+			// The package may be named after the last path element, e.g. k8s.io/api/core/v1
+			imported[path.Base(imp.path)] = true
+		}
+		imports = append(imports, imp)
+	}
+
+	// Add missing standard library imports, identifiers that are not exported by the
+	// package are declared elsewhere, e.g. a package-level log variable in another file
+	var missing []string
+	for name, selectors := range used {
+		if !imported[name] && exportsAll(stdlib[name], selectors) {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		imports = append(imports, importSpec{path: stdlib[name]})
+		changed = true
+	}
+
+	if !changed {
+		return src, nil
+	}
+	return replaceImports(fset, file, src, imports), nil
+}
+
+// usedPackages returns the identifiers used as package qualifiers in file,
+// with the selectors used with each of them
+func usedPackages(file *ast.File) map[string][]string {
+	used := make(map[string][]string)
+	ast.Inspect(file, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		// Qualifiers of imported packages are never resolved to a local object
+		if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil {
+			used[ident.Name] = append(used[ident.Name], sel.Sel.Name)
+		}
+		return true
+	})
+	return used
+}
+
+// packageName returns the name an import is referred to by, and whether
+// that name is certain. Only explicit names and standard library packages
+// are certain, the names of other packages are guessed from their path
+func packageName(imp importSpec) (string, bool) {
+	if imp.name != "" {
+		return imp.name, true
+	}
+	base := path.Base(imp.path)
+	if isStdlib(imp.path) {
+		return base, true
+	}
+
+	// Major version suffixes, e.g. example.com/pkg/v2 or gopkg.in/yaml.v3
+	if strings.HasPrefix(base, "v") && isDigits(base[1:]) && path.Dir(imp.path) != "." {
+		base = path.Base(path.Dir(imp.path))
+	}
+	if i := strings.Index(base, ".v"); i > 0 && isDigits(base[i+2:]) {
+		base = base[:i]
+	}
+	return base, false
+}
+
+var (
+	exportsOnce sync.Once
+	exports     map[string]map[string]bool // Exported identifiers by import path
+)
+
+// exportsAll reports whether the standard library package exports all identifiers
+func exportsAll(importPath string, identifiers []string) bool {
+	exportsOnce.Do(func() {
+		exports = make(map[string]map[string]bool, len(stdlibExports))
+		for p, names := range stdlibExports {
+			exports[p] = make(map[string]bool)
+			for _, name := range strings.Fields(names) {
+				exports[p][name] = true
+			}
+		}
+	})
+	names, ok := exports[importPath]
+	if !ok {
+		return false
+	}
+	for _, identifier := range identifiers {
+		if !names[identifier] {
+			return false
+		}
+	}
+	return true
+}
+
+// replaceImports replaces all import declarations of src with imports,
+// grouping standard library imports before third-party ones
+func replaceImports(fset *token.FileSet, file *ast.File, src []byte, imports []importSpec) []byte {
+	var std, other []importSpec
+	for _, imp := range imports {
+		if isStdlib(imp.path) {
+			std = append(std, imp)
+		} else {
+			other = append(other, imp)
+		}
+	}
+
+	var block bytes.Buffer
+	if len(imports) > 0 {
+		block.WriteString("import (\n")
+		writeImports(&block, std)
+		if len(std) > 0 && len(other) > 0 {
+			block.WriteString("\n")
+		}
+		writeImports(&block, other)
+		block.WriteString(")")
+	}
+
+	// Remove the existing declarations, last one first so offsets stay valid
+	var decls []*ast.GenDecl
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			decls = append(decls, gen)
+		}
+	}
+	out := append([]byte(nil), src...)
+	for i := len(decls) - 1; i >= 0; i-- {
+		start := fset.Position(decls[i].Pos()).Offset
+		end := fset.Position(decls[i].End()).Offset
+		out = append(out[:start], out[end:]...)
+	}
+
+	// Insert the new block where the first declaration was, or after the package clause
+	var insertAt int
+	var prefix []byte
+	if len(decls) > 0 {
+		insertAt = fset.Position(decls[0].Pos()).Offset
+	} else {
+		insertAt = fset.Position(file.Name.End()).Offset
+		prefix = []byte("\n\n")
+	}
+	result := make([]byte, 0, len(out)+len(prefix)+block.Len())
+	result = append(result, out[:insertAt]...)
+	result = append(result, prefix...)
+	result = append(result, block.Bytes()...)
+	result = append(result, out[insertAt:]...)
+	return result
+}
+
+// writeImports writes sorted import lines to buf
+func writeImports(buf *bytes.Buffer, imports []importSpec) {
+	sort.Slice(imports, func(i, j int) bool { return imports[i].path < imports[j].path })
+	for _, imp := range imports {
+		buf.WriteString("\t")
+		if imp.name != "" {
+			buf.WriteString(imp.name + " ")
+		}
+		buf.WriteString(strconv.Quote(imp.path) + "\n")
+	}
+}
+
+// isStdlib reports whether an import path belongs to the standard library
+func isStdlib(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// toError converts a parser error into an *Error carrying the line number
+func toError(err error) error {
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		return &Error{Line: list[0].Pos.Line, Msg: list[0].Msg}
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "formats code",
+			input:    "package model\ntype User struct {\nId string `bson:\"_id\"`\n    CreatedTime   int   \n}\n",
+			expected: "package model\n\ntype User struct {\n\tId          string `bson:\"_id\"`\n\tCreatedTime int\n}\n",
+		},
+		{
+			name:     "removes unused imports",
+			input:    "package model\n\nimport (\n\t\"context\"\n\t\"time\"\n\n\t\"go.mongodb.org/mongo-driver/bson\"\n)\n\nvar t time.Time\n",
+			expected: "package model\n\nimport (\n\t\"time\"\n\n\t\"go.mongodb.org/mongo-driver/bson\"\n)\n\nvar t time.Time\n",
+		},
+		{
+			name:     "removes the whole import block",
+			input:    "package model\n\nimport \"fmt\"\n\nvar x = 1\n",
+			expected: "package model\n\nvar x = 1\n",
+		},
+		{
+			name:     "adds missing stdlib imports",
+			input:    "package model\n\nvar t time.Time\nvar err = fmt.Errorf(\"x\")\n",
+			expected: "package model\n\nimport (\n\t\"fmt\"\n\t\"time\"\n)\n\nvar t time.Time\nvar err = fmt.Errorf(\"x\")\n",
+		},
+		{
+			name:     "adds to existing imports and groups them",
+			input:    "package model\n\nimport \"gopkg.in/yaml.v3\"\n\nvar _ = yaml.Marshal\nvar _ = strings.ToUpper\n",
+			expected: "package model\n\nimport (\n\t\"strings\"\n\n\t\"gopkg.in/yaml.v3\"\n)\n\nvar _ = yaml.Marshal\nvar _ = strings.ToUpper\n",
+		},
+		{
+			name:     "keeps blank, dot and uncertain imports",
+			input:    "package model\n\nimport (\n\t_ \"embed\"\n\t. \"strings\"\n\t\"github.com/mattn/go-sqlite3\"\n)\n",
+			expected: "package model\n\nimport (\n\t_ \"embed\"\n\t\"github.com/mattn/go-sqlite3\"\n\t. \"strings\"\n)\n",
+		},
+		{
+			name:     "keeps third-party imports",
+			input:    "package model\n\nimport (\n\t\"example.com/pkg/v2\"\n\t\"gopkg.in/yaml.v3\"\n\t\"k8s.io/api/core/v1\"\n)\n\nvar _ v1.Pod\n",
+			expected: "package model\n\nimport (\n\t\"example.com/pkg/v2\"\n\t\"gopkg.in/yaml.v3\"\n\t\"k8s.io/api/core/v1\"\n)\n\nvar _ v1.Pod\n",
+		},
+		{
+			name:     "removes unused named imports",
+			input:    "package model\n\nimport (\n\tcorev1 \"k8s.io/api/core/v1\"\n\t\"time\"\n)\n\nvar _ time.Time\n",
+			expected: "package model\n\nimport (\n\t\"time\"\n)\n\nvar _ time.Time\n",
+		},
+		{
+			name:     "ignores identifiers the package does not export",
+			input:    "package model\n\nfunc f() { log.Info(\"x\"); strings.ToUpper(\"x\") }\n",
+			expected: "package model\n\nimport (\n\t\"strings\"\n)\n\nfunc f() { log.Info(\"x\"); strings.ToUpper(\"x\") }\n",
+		},
+		{
+			name:     "ignores local variables",
+			input:    "package model\n\nfunc f(strings struct{ X int }) int { return strings.X }\n",
+			expected: "package model\n\nfunc f(strings struct{ X int }) int { return strings.X }\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Source([]byte(tc.input))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(result))
+		})
+	}
+}
+
+func TestSourceWithSyntaxError(t *testing.T) {
+	_, err := Source([]byte("package model\n\nfunc f() {\n\treturn 1 +\n}\n"))
+	assert.Error(t, err)
+
+	var formatErr *Error
+	assert.ErrorAs(t, err, &formatErr)
+	assert.Equal(t, 5, formatErr.Line)
+	assert.Contains(t, err.Error(), "line 5:")
+}
+
+func TestPackageName(t *testing.T) {
+	testCases := []struct {
+		name            string
+		imp             importSpec
+		expected        string
+		expectedCertain bool
+	}{
+		{"stdlib", importSpec{path: "context"}, "context", true},
+		{"nested stdlib", importSpec{path: "encoding/json"}, "json", true},
+		{"explicit name", importSpec{name: "mgo", path: "go.mongodb.org/mongo-driver/mongo"}, "mgo", true},
+		{"major version dir", importSpec{path: "example.com/pkg/v2"}, "pkg", false},
+		{"gopkg.in version", importSpec{path: "gopkg.in/yaml.v3"}, "yaml", false},
+		{"third-party", importSpec{path: "go.mongodb.org/mongo-driver/bson"}, "bson", false},
+		{"version as name", importSpec{path: "k8s.io/api/core/v1"}, "core", false},
+		{"dashed path", importSpec{path: "github.com/mattn/go-sqlite3"}, "go-sqlite3", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name, certain := packageName(tc.imp)
+			assert.Equal(t, tc.expected, name)
+			assert.Equal(t, tc.expectedCertain, certain)
+		})
+	}
+}
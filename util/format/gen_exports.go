@@ -0,0 +1,163 @@
+//go:build ignore
+
+// gen_exports generates exports.go, the exported identifiers of the standard
+// library packages in stdlib.go, from the API files of the installed Go toolchain.
+// The API files cover all platforms and every release up to the toolchain, so
+// the table also knows identifiers newer than the go directive of go.mod.
+//
+// Run it with go generate after changing stdlib.go or upgrading Go.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+func main() {
+	paths, err := importPaths("stdlib.go")
+	if err != nil {
+		log.Fatal(err)
+	}
+	exports, err := exportedNames(paths)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gen_exports.go from %s; DO NOT EDIT.\n\n", goVersion())
+	buf.WriteString("package format\n\n")
+	buf.WriteString("// stdlibExports lists the exported identifiers of the packages in stdlib, a\n")
+	buf.WriteString("// missing import is only added when every identifier used with it is one of them\n")
+	buf.WriteString("var stdlibExports = map[string]string{\n")
+	for _, p := range paths {
+		fmt.Fprintf(&buf, "\t%q: %q,\n", p, strings.Join(exports[p], " "))
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("exports.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// importPaths returns the sorted import paths of the stdlib map in file
+func importPaths(file string) ([]string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		kv, ok := n.(*ast.KeyValueExpr)
+		if !ok {
+			return true
+		}
+		if lit, ok := kv.Value.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			if p, err := strconv.Unquote(lit.Value); err == nil {
+				seen[p] = true
+			}
+		}
+		return false
+	})
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("no import paths in %s", file)
+	}
+	paths := make([]string, 0, len(seen))
+	for p := range seen {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// exportedNames returns the sorted exported package-level identifiers of the packages
+// in paths, read from the API files of the Go toolchain. Lines of the API files look like
+// "pkg os (linux-386), const O_APPEND = 1024" or "pkg errors, func Is(error, error) bool"
+func exportedNames(paths []string) (map[string][]string, error) {
+	goroot, err := exec.Command("go", "env", "GOROOT").Output()
+	if err != nil {
+		return nil, fmt.Errorf("go env: %w", err)
+	}
+	dir := filepath.Join(strings.TrimSpace(string(goroot)), "api")
+	files, err := filepath.Glob(filepath.Join(dir, "go1*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	next, err := filepath.Glob(filepath.Join(dir, "next", "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool)
+	for _, p := range paths {
+		wanted[p] = true
+	}
+	seen := make(map[string]map[string]bool)
+	for _, file := range append(files, next...) {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			pkg, decl, ok := strings.Cut(strings.TrimPrefix(line, "pkg "), ", ")
+			if !ok {
+				continue
+			}
+			pkg, _, _ = strings.Cut(pkg, " ")
+			if !wanted[pkg] {
+				continue
+			}
+			kind, rest, _ := strings.Cut(decl, " ")
+			if kind != "func" && kind != "type" && kind != "const" && kind != "var" {
+				continue
+			}
+			name := rest
+			if i := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' }); i >= 0 {
+				name = rest[:i]
+			}
+			if !token.IsExported(name) {
+				continue
+			}
+			if seen[pkg] == nil {
+				seen[pkg] = make(map[string]bool)
+			}
+			seen[pkg][name] = true
+		}
+	}
+
+	result := make(map[string][]string)
+	for _, p := range paths {
+		if len(seen[p]) == 0 {
+			return nil, fmt.Errorf("no exported identifiers of %s in %s", p, dir)
+		}
+		for name := range seen[p] {
+			result[p] = append(result[p], name)
+		}
+		sort.Strings(result[p])
+	}
+	return result, nil
+}
+
+// goVersion returns the version of the Go toolchain the table is generated from
+func goVersion() string {
+	out, err := exec.Command("go", "env", "GOVERSION").Output()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return strings.TrimSpace(string(out))
+}
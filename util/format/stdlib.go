@@ -0,0 +1,55 @@
+package format
+
+//go:generate go run gen_exports.go
+
+// stdlib maps package names to the standard library import paths that are
+// added automatically when generated code uses them without importing them.
+// Ambiguous names (e.g. rand, template) resolve to the most common package
+var stdlib = map[string]string{
+	"atomic":   "sync/atomic",
+	"base64":   "encoding/base64",
+	"big":      "math/big",
+	"bits":     "math/bits",
+	"bufio":    "bufio",
+	"bytes":    "bytes",
+	"context":  "context",
+	"csv":      "encoding/csv",
+	"driver":   "database/sql/driver",
+	"embed":    "embed",
+	"errors":   "errors",
+	"exec":     "os/exec",
+	"filepath": "path/filepath",
+	"fmt":      "fmt",
+	"fs":       "io/fs",
+	"hex":      "encoding/hex",
+	"http":     "net/http",
+	"io":       "io",
+	"json":     "encoding/json",
+	"log":      "log",
+	"maps":     "maps",
+	"math":     "math",
+	"md5":      "crypto/md5",
+	"net":      "net",
+	"os":       "os",
+	"path":     "path",
+	"rand":     "math/rand",
+	"reflect":  "reflect",
+	"regexp":   "regexp",
+	"runtime":  "runtime",
+	"sha1":     "crypto/sha1",
+	"sha256":   "crypto/sha256",
+	"slices":   "slices",
+	"slog":     "log/slog",
+	"sort":     "sort",
+	"sql":      "database/sql",
+	"strconv":  "strconv",
+	"strings":  "strings",
+	"sync":     "sync",
+	"template": "text/template",
+	"testing":  "testing",
+	"time":     "time",
+	"unicode":  "unicode",
+	"url":      "net/url",
+	"utf8":     "unicode/utf8",
+	"xml":      "encoding/xml",
+}
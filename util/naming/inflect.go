@@ -0,0 +1,165 @@
+package naming
+
+import (
+	"strings"
+	"unicode"
+)
+
+// irregularPlurals maps irregular singular nouns to their plural forms
+var irregularPlurals = map[string]string{
+	"person": "people",
+	"man":    "men",
+	"woman":  "women",
+	"child":  "children",
+	"tooth":  "teeth",
+	"foot":   "feet",
+	"mouse":  "mice",
+	"goose":  "geese",
+	"ox":     "oxen",
+	"leaf":   "leaves",
+	"life":   "lives",
+	"knife":  "knives",
+	"wife":   "wives",
+	"half":   "halves",
+	"shelf":  "shelves",
+	"wolf":   "wolves",
+	"index":  "indices",
+	"matrix": "matrices",
+	"datum":  "data",
+	"medium": "media",
+}
+
+// uncountables are words whose singular and plural forms are the same
+var uncountables = map[string]bool{
+	"sheep":       true,
+	"fish":        true,
+	"deer":        true,
+	"series":      true,
+	"species":     true,
+	"news":        true,
+	"info":        true,
+	"information": true,
+	"equipment":   true,
+	"metadata":    true,
+	"data":        true,
+	"media":       true,
+}
+
+// irregularSingulars is the reverse of irregularPlurals
+var irregularSingulars = func() map[string]string {
+	m := make(map[string]string, len(irregularPlurals))
+	for singular, plural := range irregularPlurals {
+		m[plural] = singular
+	}
+	return m
+}()
+
+// Pluralize returns the plural form of the last word in input,
+// keeping the rest of the input and its casing untouched
+func Pluralize(input string) string {
+	return inflectLastWord(input, pluralizeWord)
+}
+
+// Singularize returns the singular form of the last word in input,
+// keeping the rest of the input and its casing untouched
+func Singularize(input string) string {
+	return inflectLastWord(input, singularizeWord)
+}
+
+// inflectLastWord applies fn to the lowercased last word of input and
+// restores the original capitalization of that word
+func inflectLastWord(input string, fn func(string) string) string {
+	if input == "" {
+		return ""
+	}
+
+	start := lastWordStart(input)
+	prefix, word := input[:start], input[start:]
+	lower := strings.ToLower(word)
+	result := fn(lower)
+	if result == lower {
+		return input
+	}
+
+	switch {
+	case strings.HasPrefix(result, lower):
+		// Only a suffix was added, keep the word as written (ID -> IDs)
+		result = word + result[len(lower):]
+	case strings.HasPrefix(lower, result):
+		// Only a suffix was removed (Users -> User)
+		result = word[:len(result)]
+	case word == strings.ToUpper(word) && len(word) > 1:
+		result = strings.ToUpper(result)
+	case unicode.IsUpper(rune(word[0])):
+		result = strings.ToUpper(result[:1]) + result[1:]
+	}
+	return prefix + result
+}
+
+// lastWordStart returns the byte offset where the last word of s begins
+func lastWordStart(s string) int {
+	for i := len(s) - 1; i > 0; i-- {
+		c := rune(s[i])
+		if c == '_' || c == '-' || unicode.IsSpace(c) {
+			return i + 1
+		}
+		if unicode.IsUpper(c) && !unicode.IsUpper(rune(s[i-1])) {
+			return i
+		}
+	}
+	return 0
+}
+
+// pluralizeWord returns the plural form of a lowercase word
+func pluralizeWord(word string) string {
+	if uncountables[word] {
+		return word
+	}
+	if plural, ok := irregularPlurals[word]; ok {
+		return plural
+	}
+
+	switch {
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "z"),
+		strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		return word + "es"
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !isVowel(word[len(word)-2]):
+		return word[:len(word)-1] + "ies"
+	default:
+		return word + "s"
+	}
+}
+
+// singularizeWord returns the singular form of a lowercase word
+func singularizeWord(word string) string {
+	if uncountables[word] {
+		return word
+	}
+	if singular, ok := irregularSingulars[word]; ok {
+		return singular
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 3:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "zes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "s") && len(word) > 1:
+		return word[:len(word)-1]
+	default:
+		return word
+	}
+}
+
+// isVowel reports whether b is a lowercase ASCII vowel
+func isVowel(b byte) bool {
+	switch b {
+	case 'a', 'e', 'i', 'o', 'u':
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,63 @@
+package naming
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluralize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"regular", "user", "users"},
+		{"pascal", "UserProfile", "UserProfiles"},
+		{"snake", "user_profile", "user_profiles"},
+		{"kebab", "user-profile", "user-profiles"},
+		{"es suffix", "box", "boxes"},
+		{"ch suffix", "batch", "batches"},
+		{"consonant y", "category", "categories"},
+		{"vowel y", "key", "keys"},
+		{"irregular", "person", "people"},
+		{"irregular capitalized", "Person", "People"},
+		{"irregular in compound", "SalesPerson", "SalesPeople"},
+		{"uncountable", "news", "news"},
+		{"acronym", "ID", "IDs"},
+		{"empty string", "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Pluralize(tc.input))
+		})
+	}
+}
+
+func TestSingularize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"regular", "users", "user"},
+		{"pascal", "UserProfiles", "UserProfile"},
+		{"snake", "user_profiles", "user_profile"},
+		{"es suffix", "boxes", "box"},
+		{"ch suffix", "batches", "batch"},
+		{"ies suffix", "categories", "category"},
+		{"irregular", "people", "person"},
+		{"irregular capitalized", "Children", "Child"},
+		{"uncountable", "series", "series"},
+		{"already singular", "status", "status"},
+		{"double s", "address", "address"},
+		{"empty string", "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Singularize(tc.input))
+		})
+	}
+}
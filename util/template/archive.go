@@ -0,0 +1,252 @@
+package template
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxArchiveSize limits the size of a downloaded or extracted template archive
+const maxArchiveSize = 100 << 20
+
+// httpClient downloads remote template archives
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+// isArchive checks if a template source is a .tar.gz, .tgz or .zip archive,
+// either a local file or a URL
+func isArchive(source string) bool {
+	name := source
+	if strings.Contains(source, "://") {
+		u, err := url.Parse(source)
+		if err != nil {
+			return false
+		}
+		name = u.Path
+	}
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz") || strings.HasSuffix(name, ".zip")
+}
+
+// isRemoteArchive checks if an archive template source has to be downloaded
+func isRemoteArchive(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// IsTemplateURL checks if a template source is a Git repository, archive URL
+// or Go module rather than a local path
+func IsTemplateURL(source string) bool {
+	return isGitRepo(source) || isGoModule(source) || isArchive(source) && (isRemoteArchive(source) || strings.HasPrefix(source, "file://"))
+}
+
+// parseChecksum parses an expected archive checksum of the form sha256:<hex>
+func parseChecksum(checksum string) ([]byte, error) {
+	algorithm, value, ok := strings.Cut(checksum, ":")
+	sum, err := hex.DecodeString(value)
+	if !ok || algorithm != "sha256" || err != nil || len(sum) != sha256.Size {
+		return nil, fmt.Errorf("invalid template checksum %q, expected sha256:<hex>", checksum)
+	}
+	return sum, nil
+}
+
+// readArchive reads a local or remote template archive and verifies its checksum
+// if one is given
+func readArchive(source, checksum string) ([]byte, error) {
+	var expected []byte
+	if checksum != "" {
+		var err error
+		if expected, err = parseChecksum(checksum); err != nil {
+			return nil, err
+		}
+	}
+
+	var content []byte
+	var err error
+	if isRemoteArchive(source) {
+		content, err = downloadArchive(source)
+	} else {
+		content, err = readArchiveFile(strings.TrimPrefix(source, "file://"))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if expected != nil {
+		sum := sha256.Sum256(content)
+		if !bytes.Equal(sum[:], expected) {
+			return nil, fmt.Errorf("checksum mismatch for %s: got sha256:%x, expected %s", source, sum, checksum)
+		}
+	}
+	return content, nil
+}
+
+// downloadArchive downloads a remote template archive
+func downloadArchive(source string) ([]byte, error) {
+	resp, err := httpClient.Get(source)
+	if err != nil {
+		return nil, fmt.Errorf("download template: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download template %s: %s", source, resp.Status)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxArchiveSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("download template: %w", err)
+	}
+	if len(content) > maxArchiveSize {
+		return nil, fmt.Errorf("template archive %s is larger than %d bytes", source, maxArchiveSize)
+	}
+	return content, nil
+}
+
+// readArchiveFile reads a local template archive
+func readArchiveFile(name string) ([]byte, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, fmt.Errorf("read template archive: %w", err)
+	}
+	if info.Size() > maxArchiveSize {
+		return nil, fmt.Errorf("template archive %s is larger than %d bytes", name, maxArchiveSize)
+	}
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("read template archive: %w", err)
+	}
+	return content, nil
+}
+
+// extractArchive extracts a template archive into dir. Entries that would be
+// written outside dir are rejected and links are skipped
+func extractArchive(source string, content []byte, dir string) error {
+	var err error
+	if isZip(content) {
+		err = extractZip(content, dir)
+	} else {
+		err = extractTarGz(content, dir)
+	}
+	if err != nil {
+		return fmt.Errorf("extract %s: %w", source, err)
+	}
+	return nil
+}
+
+// isZip checks for the zip file signature, other archives are gzip compressed tar files
+func isZip(content []byte) bool {
+	return bytes.HasPrefix(content, []byte("PK\x03\x04"))
+}
+
+// extractTarGz extracts a gzip compressed tar archive into dir
+func extractTarGz(content []byte, dir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	var written int64
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err := archiveTarget(dir, header.Name)
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if written += header.Size; written > maxArchiveSize {
+				return fmt.Errorf("archive is larger than %d bytes", maxArchiveSize)
+			}
+			if err := writeArchiveFile(target, tr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// extractZip extracts a zip archive into dir
+func extractZip(content []byte, dir string) error {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return err
+	}
+
+	var written uint64
+	for _, file := range zr.File {
+		target, err := archiveTarget(dir, file.Name)
+		if err != nil {
+			return err
+		}
+		switch mode := file.Mode(); {
+		case mode.IsDir():
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			if written += file.UncompressedSize64; written > maxArchiveSize {
+				return fmt.Errorf("archive is larger than %d bytes", maxArchiveSize)
+			}
+			r, err := file.Open()
+			if err != nil {
+				return err
+			}
+			err = writeArchiveFile(target, r)
+			r.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// archiveTarget returns the path an archive entry is extracted to,
+// rejecting absolute paths and paths that leave dir
+func archiveTarget(dir, name string) (string, error) {
+	cleaned := path.Clean(strings.ReplaceAll(name, `\`, "/"))
+	if cleaned == "." {
+		return dir, nil
+	}
+	if strings.HasPrefix(cleaned, "/") || cleaned == ".." || strings.HasPrefix(cleaned, "../") || filepath.VolumeName(cleaned) != "" {
+		return "", fmt.Errorf("archive entry %s is outside the archive", name)
+	}
+	return filepath.Join(dir, filepath.FromSlash(cleaned)), nil
+}
+
+// writeArchiveFile writes an extracted file, creating its parent directories
+func writeArchiveFile(target string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, io.LimitReader(r, maxArchiveSize))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,316 @@
+package template
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/lewinz/go-gen/util/naming"
+	"github.com/stretchr/testify/assert"
+)
+
+// archiveEntry 测试压缩包中的一个条目
+type archiveEntry struct {
+	name    string
+	content string
+	link    string // 不为空时为指向该路径的符号链接
+}
+
+// makeTarGz 创建 .tar.gz 格式的测试压缩包
+func makeTarGz(t *testing.T, entries []archiveEntry) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0644, Size: int64(len(entry.content)), Typeflag: tar.TypeReg}
+		if entry.link != "" {
+			header = &tar.Header{Name: entry.name, Mode: 0777, Linkname: entry.link, Typeflag: tar.TypeSymlink}
+		}
+		assert.NoError(t, tw.WriteHeader(header))
+		_, err := tw.Write([]byte(entry.content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+// makeZip 创建 .zip 格式的测试压缩包
+func makeZip(t *testing.T, entries []archiveEntry) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		w, err := zw.Create(entry.name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(entry.content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestIsArchive(t *testing.T) {
+	testCases := []struct {
+		name     string
+		source   string
+		expected bool
+	}{
+		{"local tar.gz", "./templates.tar.gz", true},
+		{"local tgz", "/tmp/templates.tgz", true},
+		{"local zip", "templates.ZIP", true},
+		{"url", "https://example.com/packs/templates.tar.gz", true},
+		{"url with query", "https://example.com/templates.zip?token=abc", true},
+		{"file url", "file:///tmp/templates.tar.gz", true},
+		{"directory", "./templates", false},
+		{"git repository", "https://github.com/user/repo.git", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isArchive(tc.source))
+		})
+	}
+}
+
+func TestIsTemplateURL(t *testing.T) {
+	testCases := []struct {
+		name     string
+		source   string
+		expected bool
+	}{
+		{"git repository", "git@github.com:user/repo.git", true},
+		{"archive url", "https://example.com/templates.tar.gz", true},
+		{"file archive url", "file:///tmp/templates.zip", true},
+		{"local archive", "./templates.tar.gz", false},
+		{"local directory", "./templates", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, IsTemplateURL(tc.source))
+		})
+	}
+}
+
+func TestParseChecksum(t *testing.T) {
+	sum := sha256.Sum256([]byte("templates"))
+	valid := fmt.Sprintf("sha256:%x", sum)
+
+	testCases := []struct {
+		name        string
+		checksum    string
+		expectError bool
+	}{
+		{"valid", valid, false},
+		{"missing algorithm", fmt.Sprintf("%x", sum), true},
+		{"unsupported algorithm", fmt.Sprintf("md5:%x", sum), true},
+		{"not hex", "sha256:xyz", true},
+		{"wrong length", "sha256:abcd", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := parseChecksum(tc.checksum)
+			if tc.expectError {
+				assert.ErrorContains(t, err, "invalid template checksum")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, sum[:], result)
+		})
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	testCases := []struct {
+		name          string
+		archive       func(*testing.T, []archiveEntry) []byte
+		entries       []archiveEntry
+		expectedFiles []string
+		expectError   string
+	}{
+		{
+			name:    "tar.gz",
+			archive: makeTarGz,
+			entries: []archiveEntry{
+				{name: "go-gen.yaml", content: "name: pack\n"},
+				{name: "mongo/model.tpl", content: "package {{.PackageName}}\n"},
+			},
+			expectedFiles: []string{"go-gen.yaml", "mongo/model.tpl"},
+		},
+		{
+			name:    "zip",
+			archive: makeZip,
+			entries: []archiveEntry{
+				{name: "go-gen.yaml", content: "name: pack\n"},
+				{name: "mongo/model.tpl", content: "package {{.PackageName}}\n"},
+			},
+			expectedFiles: []string{"go-gen.yaml", "mongo/model.tpl"},
+		},
+		{
+			name:    "symlinks are skipped",
+			archive: makeTarGz,
+			entries: []archiveEntry{
+				{name: "model.tpl", content: "package {{.PackageName}}\n"},
+				{name: "passwd.tpl", link: "/etc/passwd"},
+			},
+			expectedFiles: []string{"model.tpl"},
+		},
+		{
+			name:        "path traversal in tar.gz",
+			archive:     makeTarGz,
+			entries:     []archiveEntry{{name: "../evil.tpl", content: "evil"}},
+			expectError: "outside the archive",
+		},
+		{
+			name:        "absolute path in tar.gz",
+			archive:     makeTarGz,
+			entries:     []archiveEntry{{name: "/tmp/evil.tpl", content: "evil"}},
+			expectError: "outside the archive",
+		},
+		{
+			name:        "path traversal in zip",
+			archive:     makeZip,
+			entries:     []archiveEntry{{name: `mongo\..\..\evil.tpl`, content: "evil"}},
+			expectError: "outside the archive",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parent := t.TempDir()
+			dir := filepath.Join(parent, "extract")
+			err := os.MkdirAll(dir, 0755)
+			assert.NoError(t, err)
+
+			err = extractArchive("templates", tc.archive(t, tc.entries), dir)
+			if tc.expectError != "" {
+				assert.ErrorContains(t, err, tc.expectError)
+				// 不会在解压目录之外写入任何文件
+				files, err := os.ReadDir(parent)
+				assert.NoError(t, err)
+				assert.Len(t, files, 1)
+				return
+			}
+			assert.NoError(t, err)
+
+			var files []string
+			err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+				rel, err := filepath.Rel(dir, path)
+				files = append(files, filepath.ToSlash(rel))
+				return err
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedFiles, files)
+		})
+	}
+}
+
+func TestGenerateWithArchive(t *testing.T) {
+	content := makeTarGz(t, []archiveEntry{
+		{name: "templates/mongo/model.tpl", content: "package {{.PackageName}}\n\ntype {{.TypePascal}} struct{}\n"},
+		{name: "templates/mongo/README.md", content: "not a template"},
+	})
+	sum := sha256.Sum256(content)
+	checksum := fmt.Sprintf("sha256:%x", sum)
+
+	archive := filepath.Join(t.TempDir(), "templates.tar.gz")
+	err := os.WriteFile(archive, content, 0644)
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/templates.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name        string
+		source      string
+		opts        []Option
+		expectError string
+	}{
+		{"local archive", archive, nil, ""},
+		{"file url", "file://" + filepath.ToSlash(archive), nil, ""},
+		{"matching checksum", archive, []Option{WithChecksum(checksum)}, ""},
+		{"remote archive", server.URL + "/templates.tar.gz", []Option{WithChecksum(checksum)}, ""},
+		{"checksum mismatch", archive, []Option{WithChecksum("sha256:" + fmt.Sprintf("%x", sha256.Sum256(nil)))}, "checksum mismatch"},
+		{"invalid checksum", archive, []Option{WithChecksum("md5:abc")}, "invalid template checksum"},
+		{"missing remote archive", server.URL + "/missing.tar.gz", nil, "404 Not Found"},
+		{"offline remote archive", server.URL + "/templates.tar.gz", []Option{WithOffline(true)}, "cannot be downloaded offline"},
+		{"checksum without archive", t.TempDir(), []Option{WithChecksum(checksum)}, "requires an archive or Go module template"},
+		{"ref with archive", archive, []Option{WithRef("v1.4.0")}, "requires a Git or Go module template"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			outputDir := filepath.Join(t.TempDir(), "model")
+			err := os.MkdirAll(outputDir, 0755)
+			assert.NoError(t, err)
+			opts := append([]Option{WithPath("templates/mongo")}, tc.opts...)
+			engine := NewEngine(naming.StyleSnake, opts...)
+			err = engine.Generate(tc.source, outputDir, "user")
+			if tc.expectError != "" {
+				assert.ErrorContains(t, err, tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+
+			result, err := os.ReadFile(filepath.Join(outputDir, "user_model.go"))
+			assert.NoError(t, err)
+			assert.Equal(t, "package model\n\ntype User struct{}\n", string(result))
+		})
+	}
+}
+
+func TestGenerateWithFileRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GO_GEN_CACHE", t.TempDir())
+
+	// 创建本地 Git 仓库，通过 file:// 地址使用真实的 git 命令
+	repoDir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(repoDir, "templates"), 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(repoDir, "templates", "model.tpl"), []byte("package {{.PackageName}}\n"), 0644)
+	assert.NoError(t, err)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+		{"tag", "v1.0.0"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		output, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(output))
+	}
+
+	outputDir := filepath.Join(t.TempDir(), "model")
+	err = os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	engine := NewEngine(naming.StyleSnake)
+	err = engine.Generate("file://"+filepath.ToSlash(repoDir)+"//templates@v1.0.0", outputDir, "user")
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_model.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package model\n", string(content))
+}
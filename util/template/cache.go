@@ -0,0 +1,627 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Environment variables that choose the cache directory
+const (
+	cacheEnv    = "GO_GEN_CACHE"   // Overrides the cache directory
+	xdgCacheEnv = "XDG_CACHE_HOME" // Base directory for user caches
+)
+
+// tmpPrefix is the name prefix of temporary files and directories in the cache
+const tmpPrefix = ".tmp-"
+
+// cacheKeyPattern matches the names of cache entries, e.g. templates-3f2a9c1b0d4e
+var cacheKeyPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+-[0-9a-f]{12}$`)
+
+// tmpKeyPattern matches the names of temporary files of cache entries and captures
+// the entry name, e.g. .tmp-templates-3f2a9c1b0d4e-1234567 or .tmp-templates-3f2a9c1b0d4e.old
+var tmpKeyPattern = regexp.MustCompile(`^` + regexp.QuoteMeta(tmpPrefix) + `([A-Za-z0-9._-]+-[0-9a-f]{12})[-.]`)
+
+// CacheEntry is a cached clone of a Git template repository
+type CacheEntry struct {
+	Key      string    `json:"-"`               // Entry name, derived from the URL and ref
+	Path     string    `json:"-"`               // Directory of the clone
+	URL      string    `json:"url"`             // Repository URL
+	Ref      string    `json:"ref"`             // Pinned tag, branch or commit, empty for HEAD
+	Commit   string    `json:"commit"`          // Checked out commit
+	Paths    []string  `json:"paths,omitempty"` // Checked out directories of a sparse checkout, empty for all files
+	Updated  time.Time `json:"updated"`         // Time of the last clone
+	LastUsed time.Time `json:"lastUsed"`        // Time the entry was last used for generation
+}
+
+// CacheRoot returns the template cache directory: $GO_GEN_CACHE if set,
+// $XDG_CACHE_HOME/go-gen if set, ~/.go-gen otherwise
+func CacheRoot() (string, error) {
+	if dir := os.Getenv(cacheEnv); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv(xdgCacheEnv); dir != "" {
+		return filepath.Join(dir, "go-gen"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home dir: %w", err)
+	}
+	return filepath.Join(homeDir, cacheDir), nil
+}
+
+// cacheKey returns the entry name of a repository URL and ref. The hash of the
+// full URL keeps repositories with the same name apart
+func cacheKey(repoURL, ref string) string {
+	sum := sha256.Sum256([]byte(repoURL + "@" + ref))
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, getRepoName(repoURL))
+	if name == "" {
+		name = "repo"
+	}
+	return name + "-" + hex.EncodeToString(sum[:])[:12]
+}
+
+// newCacheEntry returns the cache entry of a repository URL and ref in root,
+// with the saved metadata if there is any
+func newCacheEntry(root, repoURL, ref string) *CacheEntry {
+	key := cacheKey(repoURL, ref)
+	entry := &CacheEntry{
+		Key:  key,
+		Path: filepath.Join(root, key),
+		URL:  repoURL,
+		Ref:  ref,
+	}
+	if saved, err := loadCacheEntry(root, key); err == nil {
+		entry.Commit = saved.Commit
+		entry.Updated = saved.Updated
+		entry.LastUsed = saved.LastUsed
+		entry.Paths = saved.Paths
+	}
+	return entry
+}
+
+// loadCacheEntry reads the metadata of a cache entry
+func loadCacheEntry(root, key string) (*CacheEntry, error) {
+	content, err := os.ReadFile(filepath.Join(root, key+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return nil, fmt.Errorf("parse cache entry %s: %w", key, err)
+	}
+	entry.Key = key
+	entry.Path = filepath.Join(root, key)
+	return &entry, nil
+}
+
+// save writes the metadata of a cache entry next to its directory. It is written
+// to a temporary file first so concurrent readers never see a partial file
+func (c *CacheEntry) save() error {
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(c.Path), tmpPrefix+c.Key+"-*.json")
+	if err != nil {
+		return fmt.Errorf("save cache entry %s: %w", c.Key, err)
+	}
+	_, err = file.Write(append(content, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), c.Path+".json")
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("save cache entry %s: %w", c.Key, err)
+	}
+	return nil
+}
+
+// remove deletes the directory and metadata of a cache entry
+func (c *CacheEntry) remove() error {
+	if err := os.RemoveAll(c.Path); err != nil {
+		return fmt.Errorf("remove cache entry %s: %w", c.Key, err)
+	}
+	if err := os.Remove(c.Path + ".json"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove cache entry %s: %w", c.Key, err)
+	}
+	return nil
+}
+
+// remoteError reports that the remote repository could not be reached
+type remoteError struct {
+	err error
+}
+
+// Error implements the error interface
+func (e *remoteError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *remoteError) Unwrap() error {
+	return e.err
+}
+
+// getCachedTemplate gets or creates a cached template.
+// A non-empty ref pins a tag, branch or commit, each ref is cached separately.
+// Only the directories in paths are checked out, all files if paths is empty.
+// In offline mode the remote is never contacted and the template must already be cached.
+// The returned shared lock keeps other processes from updating or removing the entry
+// while the caller reads it, the caller releases it when done
+func getCachedTemplate(repoURL, ref string, paths []string, offline bool) (string, *fileLock, error) {
+	// Create cache directory if not exists
+	root, err := CacheRoot()
+	if err != nil {
+		return "", nil, err
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", nil, fmt.Errorf("create cache dir: %w", err)
+	}
+	lockPath := filepath.Join(root, cacheKey(repoURL, ref)+".lock")
+
+	if offline {
+		lock, err := rlockFile(lockPath)
+		if err != nil {
+			return "", nil, err
+		}
+		entry := newCacheEntry(root, repoURL, ref)
+		if _, err := os.Stat(entry.Path); err != nil || !entry.covers(paths) {
+			lock.unlock()
+			return "", nil, fmt.Errorf("template %s is not cached and cannot be fetched offline", source(repoURL, ref))
+		}
+		return entry.use(lock)
+	}
+
+	repoHash, err := resolveRef(repoURL, ref)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Use the cached copy if it is up to date
+	lock, err := rlockFile(lockPath)
+	if err != nil {
+		return "", nil, err
+	}
+	entry := newCacheEntry(root, repoURL, ref)
+	if ok, _ := entry.upToDate(ref, repoHash, paths); ok {
+		return entry.use(lock)
+	}
+	lock.unlock()
+
+	// Update the entry while no other process uses it, then read it like any other process
+	lock, err = lockFile(lockPath)
+	if err != nil {
+		return "", nil, err
+	}
+	err = updateCachedTemplate(root, repoURL, ref, repoHash, paths)
+	lock.unlock()
+	if err != nil {
+		return "", nil, err
+	}
+	if lock, err = rlockFile(lockPath); err != nil {
+		return "", nil, err
+	}
+	return newCacheEntry(root, repoURL, ref).use(lock)
+}
+
+// resolveRef returns the commit to use for a ref. A full commit hash needs no lookup,
+// an unknown ref may be an abbreviated hash that is resolved by checkout, in which
+// case the returned commit is empty
+func resolveRef(repoURL, ref string) (string, error) {
+	if isCommitHash(ref) {
+		return ref, nil
+	}
+	repoHash, err := getRepoHash(repoURL, ref)
+	if err != nil {
+		return "", &remoteError{fmt.Errorf("get repo hash: %w", err)}
+	}
+	if repoHash == "" && (len(ref) < 7 || !isHex(ref)) {
+		return "", fmt.Errorf("ref %s not found in %s", ref, repoURL)
+	}
+	return repoHash, nil
+}
+
+// upToDate reports whether the entry is checked out at the pinned commit with
+// the directories in paths, and sets its commit. It returns the checked out
+// commit, an error if the entry has no clone
+func (c *CacheEntry) upToDate(ref, repoHash string, paths []string) (bool, error) {
+	if _, err := os.Stat(c.Path); err != nil {
+		return false, err
+	}
+	currentHash, err := getCurrentHash(c.Path)
+	if err != nil {
+		return false, err
+	}
+	if currentHash != repoHash && (repoHash != "" || !strings.HasPrefix(currentHash, ref)) || !c.covers(paths) {
+		return false, nil
+	}
+	c.Commit = currentHash
+	return true, nil
+}
+
+// use records that the entry is used for generation and returns its directory
+// with the lock held by the caller
+func (c *CacheEntry) use(lock *fileLock) (string, *fileLock, error) {
+	c.LastUsed = time.Now()
+	if err := c.save(); err != nil {
+		lock.unlock()
+		return "", nil, err
+	}
+	return c.Path, lock, nil
+}
+
+// updateCachedTemplate checks out the pinned commit of a cache entry, the caller
+// holds the exclusive lock of the entry. The last use of the entry is left unchanged
+func updateCachedTemplate(root, repoURL, ref, repoHash string, paths []string) error {
+	// Check if cached version exists, another process may have just updated it
+	entry := newCacheEntry(root, repoURL, ref)
+	ok, err := entry.upToDate(ref, repoHash, paths)
+	if ok {
+		return entry.save()
+	}
+	if err == nil {
+		// Update the existing clone in place, no other process reads it while the
+		// lock is held. It is cloned again if that fails
+		if err := updateRepo(entry, ref, repoHash, entry.sparsePaths(paths)); err == nil {
+			return entry.save()
+		}
+	}
+
+	// Clone into a temporary directory, then move it into place so that
+	// the cached copy is never seen half-written
+	tmpDir, err := os.MkdirTemp(root, tmpPrefix+entry.Key+"-")
+	if err != nil {
+		return fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := cloneRepo(repoURL, ref, repoHash, paths, tmpDir); err != nil {
+		return err
+	}
+
+	entry.Commit = repoHash
+	if entry.Commit == "" {
+		if entry.Commit, err = getCurrentHash(tmpDir); err != nil {
+			return err
+		}
+	}
+	if err := replaceDir(tmpDir, entry.Path); err != nil {
+		return err
+	}
+	entry.Paths = paths
+	entry.Updated = time.Now()
+	return entry.save()
+}
+
+// covers reports whether the directories in paths are checked out in the entry
+func (c *CacheEntry) covers(paths []string) bool {
+	if len(c.Paths) == 0 {
+		return true
+	}
+	if len(paths) == 0 {
+		return false
+	}
+	for _, dir := range paths {
+		if !containsDir(c.Paths, dir) {
+			return false
+		}
+	}
+	return true
+}
+
+// sparsePaths returns the directories to check out so that the entry covers
+// both its current directories and paths
+func (c *CacheEntry) sparsePaths(paths []string) []string {
+	if len(c.Paths) == 0 || len(paths) == 0 {
+		return nil
+	}
+	merged := slices.Clone(c.Paths)
+	for _, dir := range paths {
+		if !containsDir(merged, dir) {
+			merged = append(merged, dir)
+		}
+	}
+	return merged
+}
+
+// containsDir reports whether dir is one of dirs or inside one of them
+func containsDir(dirs []string, dir string) bool {
+	return slices.ContainsFunc(dirs, func(d string) bool {
+		return dir == d || strings.HasPrefix(dir, d+"/")
+	})
+}
+
+// replaceDir moves the directory src to dst, replacing dst if it exists.
+// dst is moved aside first because renaming onto an existing directory fails on Windows
+func replaceDir(src, dst string) error {
+	if _, err := os.Stat(dst); err == nil {
+		old := filepath.Join(filepath.Dir(dst), tmpPrefix+filepath.Base(dst)+".old")
+		os.RemoveAll(old)
+		if err := os.Rename(dst, old); err != nil {
+			return fmt.Errorf("replace %s: %w", dst, err)
+		}
+		defer os.RemoveAll(old)
+		if err := os.Rename(src, dst); err != nil {
+			os.Rename(old, dst)
+			return fmt.Errorf("replace %s: %w", dst, err)
+		}
+		return nil
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return fmt.Errorf("move clone into %s: %w", dst, err)
+	}
+	return nil
+}
+
+// source formats a repository URL and ref as accepted by --template
+func source(repoURL, ref string) string {
+	if ref == "" {
+		return repoURL
+	}
+	return repoURL + "@" + ref
+}
+
+// cloneRepo creates a shallow, partial clone of a repository in the empty directory
+// path. Only the directories in paths are checked out, all files if paths is empty
+func cloneRepo(repoURL, ref, repoHash string, paths []string, path string) error {
+	if err := runGit(path, "init", "-q"); err != nil {
+		return err
+	}
+	if err := runGit(path, "remote", "add", "origin", repoURL); err != nil {
+		return err
+	}
+	if len(paths) > 0 {
+		if err := setSparseCheckout(path, paths); err != nil {
+			return err
+		}
+	}
+	return fetchCommit(path, ref, repoHash)
+}
+
+// updateRepo fetches the pinned commit into an existing clone and checks it out
+// with the directories in paths
+func updateRepo(entry *CacheEntry, ref, repoHash string, paths []string) error {
+	if !slices.Equal(entry.Paths, paths) {
+		if err := setSparseCheckout(entry.Path, paths); err != nil {
+			return err
+		}
+		entry.Paths = paths
+	}
+	if err := fetchCommit(entry.Path, ref, repoHash); err != nil {
+		return err
+	}
+	commit, err := getCurrentHash(entry.Path)
+	if err != nil {
+		return err
+	}
+	if commit != entry.Commit {
+		entry.Updated = time.Now()
+	}
+	entry.Commit = commit
+	return nil
+}
+
+// setSparseCheckout limits the working tree of a clone to the directories in paths,
+// all files are checked out if paths is empty
+func setSparseCheckout(path string, paths []string) error {
+	if len(paths) == 0 {
+		return runGit(path, "sparse-checkout", "disable")
+	}
+	return runGit(path, append([]string{"sparse-checkout", "set", "--cone", "--"}, paths...)...)
+}
+
+// fetchCommit fetches the pinned commit of a clone without history and file contents,
+// then checks it out. File contents are fetched on checkout for the checked out paths only
+func fetchCommit(path, ref, repoHash string) error {
+	if repoHash == "" {
+		// An abbreviated hash can only be resolved locally, so the history is needed
+		if err := runGit(path, "fetch", "-q", "--filter=blob:none", "--tags", "origin"); err != nil {
+			return err
+		}
+		return runGit(path, "checkout", "-q", "--force", "--detach", ref)
+	}
+	if err := runGit(path, "fetch", "-q", "--depth", "1", "--filter=blob:none", "origin", repoHash); err != nil {
+		return err
+	}
+	return runGit(path, "checkout", "-q", "--force", "--detach", "FETCH_HEAD")
+}
+
+// runGit runs a git command in dir
+func runGit(dir string, args ...string) error {
+	cmd := defaultCommander.Command("git", args...)
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// ListCache returns the cached template repositories sorted by URL and ref
+func ListCache() ([]CacheEntry, error) {
+	root, err := CacheRoot()
+	if err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cache dir: %w", err)
+	}
+
+	var entries []CacheEntry
+	for _, file := range files {
+		key, ok := strings.CutSuffix(file.Name(), ".json")
+		if !ok || file.IsDir() || !cacheKeyPattern.MatchString(key) {
+			continue
+		}
+		entry, err := loadCacheEntry(root, key)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].URL != entries[j].URL {
+			return entries[i].URL < entries[j].URL
+		}
+		return entries[i].Ref < entries[j].Ref
+	})
+	return entries, nil
+}
+
+// UpdateCache fetches the latest commit of a cached repository's ref and
+// returns the updated entry. Entries pinned to a full commit hash never change.
+// Updating does not count as a use, so it does not keep entries from being pruned
+func UpdateCache(entry CacheEntry) (CacheEntry, error) {
+	root, err := CacheRoot()
+	if err != nil {
+		return entry, err
+	}
+	key := cacheKey(entry.URL, entry.Ref)
+	if _, err := os.Stat(filepath.Join(root, key)); err != nil {
+		return entry, fmt.Errorf("template %s is not cached", source(entry.URL, entry.Ref))
+	}
+	repoHash, err := resolveRef(entry.URL, entry.Ref)
+	if err != nil {
+		return entry, err
+	}
+
+	lock, err := lockFile(filepath.Join(root, key+".lock"))
+	if err != nil {
+		return entry, err
+	}
+	err = updateCachedTemplate(root, entry.URL, entry.Ref, repoHash, entry.Paths)
+	lock.unlock()
+	if err != nil {
+		return entry, err
+	}
+	updated, err := loadCacheEntry(root, key)
+	if err != nil {
+		return entry, err
+	}
+	return *updated, nil
+}
+
+// PruneCache removes cache entries that have not been used for generation
+// within maxAge, as well as incomplete entries, and returns their keys
+func PruneCache(maxAge time.Duration) ([]string, error) {
+	cutoff := time.Now().Add(-maxAge)
+	return removeCacheEntries(func(entry *CacheEntry) bool {
+		return entry.LastUsed.After(cutoff)
+	})
+}
+
+// CleanCache removes all cache entries and returns their keys
+func CleanCache() ([]string, error) {
+	return removeCacheEntries(func(*CacheEntry) bool {
+		return false
+	})
+}
+
+// removeCacheEntries removes the cache entries keep returns false for, and
+// incomplete entries. Files in the cache directory that are not cache entries
+// are left alone, so a misconfigured GO_GEN_CACHE cannot delete unrelated data
+func removeCacheEntries(keep func(*CacheEntry) bool) ([]string, error) {
+	root, err := CacheRoot()
+	if err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cache dir: %w", err)
+	}
+
+	// Collect entries that have a directory, metadata or both, and the temporary
+	// files of each entry, which are left behind by interrupted clones
+	keys := make(map[string]bool)
+	tmpFiles := make(map[string][]string)
+	for _, file := range files {
+		key := strings.TrimSuffix(file.Name(), ".json")
+		if cacheKeyPattern.MatchString(key) {
+			keys[key] = true
+		}
+		if match := tmpKeyPattern.FindStringSubmatch(file.Name()); match != nil {
+			tmpFiles[match[1]] = append(tmpFiles[match[1]], file.Name())
+		}
+	}
+
+	var removed []string
+	for key := range keys {
+		// Wait for processes that are using or updating the entry
+		lock, err := lockFile(filepath.Join(root, key+".lock"))
+		if err != nil {
+			return removed, err
+		}
+		removeTmpFiles(root, tmpFiles[key])
+		ok, err := removeCacheEntry(root, key, keep)
+		if ok {
+			err = lock.remove()
+		} else {
+			lock.unlock()
+		}
+		if err != nil {
+			return removed, err
+		}
+		if ok {
+			removed = append(removed, key)
+		}
+	}
+	for key, names := range tmpFiles {
+		if !keys[key] {
+			if lock, err := lockFile(filepath.Join(root, key+".lock")); err == nil {
+				removeTmpFiles(root, names)
+				lock.remove()
+			}
+		}
+	}
+	sort.Strings(removed)
+	return removed, nil
+}
+
+// removeTmpFiles removes temporary files of a cache entry, the caller holds its lock
+// so they are not in use by a running clone
+func removeTmpFiles(root string, names []string) {
+	for _, name := range names {
+		os.RemoveAll(filepath.Join(root, name))
+	}
+}
+
+// removeCacheEntry removes a cache entry unless it is complete and keep returns
+// true for it, and reports whether it was removed
+func removeCacheEntry(root, key string, keep func(*CacheEntry) bool) (bool, error) {
+	entry, err := loadCacheEntry(root, key)
+	if err == nil {
+		if _, statErr := os.Stat(entry.Path); statErr == nil && keep(entry) {
+			return false, nil
+		}
+	} else {
+		entry = &CacheEntry{Key: key, Path: filepath.Join(root, key)}
+	}
+	if err := entry.remove(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,255 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheRoot(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	testCases := []struct {
+		name     string
+		cache    string
+		xdg      string
+		expected string
+	}{
+		{"default", "", "", filepath.Join(homeDir, ".go-gen")},
+		{"xdg", "", "/tmp/xdg", filepath.Join("/tmp/xdg", "go-gen")},
+		{"override", "/tmp/cache", "/tmp/xdg", "/tmp/cache"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("GO_GEN_CACHE", tc.cache)
+			t.Setenv("XDG_CACHE_HOME", tc.xdg)
+			root, err := CacheRoot()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, root)
+		})
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	a := cacheKey("https://github.com/a/templates", "")
+	b := cacheKey("https://github.com/b/templates", "")
+	assert.NotEqual(t, a, b)
+	assert.Regexp(t, `^templates-[0-9a-f]{12}$`, a)
+	assert.Regexp(t, cacheKeyPattern, a)
+
+	// 同一仓库的不同 ref 分别缓存
+	assert.NotEqual(t, a, cacheKey("https://github.com/a/templates", "v1.0.0"))
+	assert.Equal(t, a, cacheKey("https://github.com/a/templates", ""))
+
+	// 仓库名中的特殊字符被替换
+	assert.Regexp(t, `^my_repo-[0-9a-f]{12}$`, cacheKey("https://example.com/my repo.git", ""))
+}
+
+// writeCacheEntry 在缓存目录中创建一个缓存条目
+func writeCacheEntry(t *testing.T, root, url, ref string, lastUsed time.Time) *CacheEntry {
+	entry := newCacheEntry(root, url, ref)
+	entry.Commit = "abcdef1234567890"
+	entry.Updated = lastUsed
+	entry.LastUsed = lastUsed
+	err := os.MkdirAll(entry.Path, 0755)
+	assert.NoError(t, err)
+	err = entry.save()
+	assert.NoError(t, err)
+	return entry
+}
+
+func TestListCache(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("GO_GEN_CACHE", root)
+
+	// 缓存目录为空
+	entries, err := ListCache()
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	now := time.Now().Truncate(time.Second)
+	writeCacheEntry(t, root, "https://github.com/b/templates", "", now)
+	writeCacheEntry(t, root, "https://github.com/a/templates", "v1.0.0", now)
+	writeCacheEntry(t, root, "https://github.com/a/templates", "", now)
+
+	// 与缓存无关的文件被忽略
+	err = os.WriteFile(filepath.Join(root, "notes.json"), []byte("{}"), 0644)
+	assert.NoError(t, err)
+
+	entries, err = ListCache()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "https://github.com/a/templates", entries[0].URL)
+	assert.Equal(t, "", entries[0].Ref)
+	assert.Equal(t, "v1.0.0", entries[1].Ref)
+	assert.Equal(t, "https://github.com/b/templates", entries[2].URL)
+	assert.Equal(t, filepath.Join(root, entries[2].Key), entries[2].Path)
+	assert.Equal(t, "abcdef1234567890", entries[2].Commit)
+	assert.True(t, now.Equal(entries[2].LastUsed))
+
+	// 缓存目录不存在
+	t.Setenv("GO_GEN_CACHE", filepath.Join(root, "missing"))
+	entries, err = ListCache()
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestPruneCache(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("GO_GEN_CACHE", root)
+
+	recent := writeCacheEntry(t, root, "https://github.com/a/templates", "", time.Now())
+	old := writeCacheEntry(t, root, "https://github.com/b/templates", "", time.Now().Add(-48*time.Hour))
+
+	// 只有目录没有元数据的条目，以及目录已被删除的条目
+	orphan := filepath.Join(root, cacheKey("https://github.com/c/templates", ""))
+	err := os.MkdirAll(orphan, 0755)
+	assert.NoError(t, err)
+	missing := writeCacheEntry(t, root, "https://github.com/d/templates", "", time.Now())
+	err = os.RemoveAll(missing.Path)
+	assert.NoError(t, err)
+
+	// 与缓存无关的目录不会被删除
+	unrelated := filepath.Join(root, "repo")
+	err = os.MkdirAll(unrelated, 0755)
+	assert.NoError(t, err)
+
+	// 中断的克隆留下的临时文件和锁文件被删除
+	tmpFiles := []string{
+		filepath.Join(root, tmpPrefix+recent.Key+"-123456"),
+		filepath.Join(root, tmpPrefix+cacheKey("https://github.com/e/templates", "")+".old"),
+	}
+	for _, name := range tmpFiles {
+		err = os.MkdirAll(name, 0755)
+		assert.NoError(t, err)
+	}
+	err = os.WriteFile(old.Path+".lock", nil, 0644)
+	assert.NoError(t, err)
+
+	removed, err := PruneCache(24 * time.Hour)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{old.Key, filepath.Base(orphan), missing.Key}, removed)
+
+	_, err = os.Stat(recent.Path)
+	assert.NoError(t, err)
+	_, err = os.Stat(old.Path)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(old.Path + ".json")
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(orphan)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(unrelated)
+	assert.NoError(t, err)
+	for _, name := range append(tmpFiles, old.Path+".lock") {
+		_, err = os.Stat(name)
+		assert.True(t, os.IsNotExist(err), name)
+	}
+}
+
+func TestCleanCache(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("GO_GEN_CACHE", root)
+
+	a := writeCacheEntry(t, root, "https://github.com/a/templates", "", time.Now())
+	b := writeCacheEntry(t, root, "https://github.com/b/templates", "", time.Now())
+	err := os.WriteFile(filepath.Join(root, "notes.txt"), []byte("keep"), 0644)
+	assert.NoError(t, err)
+
+	removed, err := CleanCache()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{a.Key, b.Key}, removed)
+
+	files, err := os.ReadDir(root)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "notes.txt", files[0].Name())
+}
+
+func TestGetCachedTemplateSavesEntry(t *testing.T) {
+	// 保存原始的命令执行器
+	oldCommander := defaultCommander
+	defer func() { defaultCommander = oldCommander }()
+	defaultCommander = &MockCommander{}
+
+	root := t.TempDir()
+	t.Setenv("GO_GEN_CACHE", root)
+
+	const repoURL = "https://github.com/user/repo"
+	path, lock, err := getCachedTemplate(repoURL, "v1.4.0", nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, cacheKey(repoURL, "v1.4.0")), path)
+	assert.NoError(t, lock.unlock())
+
+	entries, err := ListCache()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, repoURL, entries[0].URL)
+	assert.Equal(t, "v1.4.0", entries[0].Ref)
+	assert.Equal(t, "abcdef1234567890", entries[0].Commit)
+	assert.False(t, entries[0].Updated.IsZero())
+	assert.False(t, entries[0].LastUsed.IsZero())
+
+	// 更新已缓存的条目，不改变最后使用时间
+	updated, err := UpdateCache(entries[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "abcdef1234567890", updated.Commit)
+	assert.True(t, updated.LastUsed.Equal(entries[0].LastUsed))
+}
+
+func TestUpdateCacheKeepsLastUsed(t *testing.T) {
+	oldCommander := defaultCommander
+	defer func() { defaultCommander = oldCommander }()
+	defaultCommander = &MockCommander{}
+
+	root := t.TempDir()
+	t.Setenv("GO_GEN_CACHE", root)
+
+	lastUsed := time.Now().Add(-48 * time.Hour)
+	entry := writeCacheEntry(t, root, "https://github.com/user/repo", "", lastUsed)
+
+	// 更新不算作使用，条目仍会被清理
+	updated, err := UpdateCache(*entry)
+	assert.NoError(t, err)
+	assert.True(t, lastUsed.Equal(updated.LastUsed))
+
+	removed, err := PruneCache(24 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{entry.Key}, removed)
+}
+
+func TestUpdateCacheNotCached(t *testing.T) {
+	t.Setenv("GO_GEN_CACHE", t.TempDir())
+
+	_, err := UpdateCache(CacheEntry{URL: "https://github.com/user/repo", Ref: "v1.4.0"})
+	assert.EqualError(t, err, "template https://github.com/user/repo@v1.4.0 is not cached")
+}
+
+func TestCacheEntryCovers(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cached   []string
+		paths    []string
+		covers   bool
+		expected []string
+	}{
+		{"full checkout", nil, []string{"templates"}, true, nil},
+		{"full checkout requested", []string{"templates"}, nil, false, nil},
+		{"same path", []string{"templates"}, []string{"templates"}, true, []string{"templates"}},
+		{"nested path", []string{"templates"}, []string{"templates/mongo"}, true, []string{"templates"}},
+		{"sibling with common prefix", []string{"templates"}, []string{"templates-v2"}, false, []string{"templates", "templates-v2"}},
+		{"other path", []string{"templates/mongo"}, []string{"templates/mysql"}, false, []string{"templates/mongo", "templates/mysql"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			entry := &CacheEntry{Paths: tc.cached}
+			assert.Equal(t, tc.covers, entry.covers(tc.paths))
+			assert.Equal(t, tc.expected, entry.sparsePaths(tc.paths))
+		})
+	}
+}
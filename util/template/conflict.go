@@ -0,0 +1,75 @@
+package template
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ConflictPolicy 输出文件已存在且内容不同时的处理策略
+type ConflictPolicy string
+
+const (
+	ConflictSkip      ConflictPolicy = "skip"      // 保留已有文件
+	ConflictOverwrite ConflictPolicy = "overwrite" // 覆盖已有文件
+	ConflictBackup    ConflictPolicy = "backup"    // 覆盖前将已有文件备份为 .bak
+	ConflictFail      ConflictPolicy = "fail"      // 报错并停止生成
+	ConflictPrompt    ConflictPolicy = "prompt"    // 逐个询问是否覆盖
+)
+
+// backupSuffix 备份文件的后缀
+const backupSuffix = ".bak"
+
+// ParseConflictPolicy 解析冲突处理策略，空字符串表示未指定
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(s); policy {
+	case "", ConflictSkip, ConflictOverwrite, ConflictBackup, ConflictFail, ConflictPrompt:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid conflict policy %q, expected skip|overwrite|backup|fail|prompt", s)
+	}
+}
+
+// conflictPolicy 返回文件实际使用的冲突处理策略
+//
+// 命令行指定的策略优先，其次是模板指令中的默认策略，都未指定时覆盖已有文件
+func (e *Engine) conflictPolicy(file renderedFile) ConflictPolicy {
+	if e.onConflict != "" {
+		return e.onConflict
+	}
+	if file.onConflict != "" {
+		return file.onConflict
+	}
+	return ConflictOverwrite
+}
+
+// confirmOverwrite 询问是否覆盖已有文件，输入结束或回答其他内容时不覆盖
+func (e *Engine) confirmOverwrite(name string) (bool, error) {
+	if e.input == nil {
+		e.input = bufio.NewReader(os.Stdin)
+	}
+	fmt.Fprintf(e.out, "%s already exists, overwrite? [y/N] ", name)
+	answer, err := e.input.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("read answer: %w", err)
+	}
+	if err == io.EOF {
+		fmt.Fprintln(e.out)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// backupFile 将已有文件的内容备份到同目录下的 .bak 文件
+func backupFile(path string, content []byte) error {
+	if err := os.WriteFile(path+backupSuffix, content, 0644); err != nil {
+		return fmt.Errorf("backup %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package template
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lewinz/go-gen/util/naming"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConflictPolicy(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       string
+		expected    ConflictPolicy
+		expectError bool
+	}{
+		{"empty", "", "", false},
+		{"skip", "skip", ConflictSkip, false},
+		{"overwrite", "overwrite", ConflictOverwrite, false},
+		{"backup", "backup", ConflictBackup, false},
+		{"fail", "fail", ConflictFail, false},
+		{"prompt", "prompt", ConflictPrompt, false},
+		{"invalid", "merge", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy, err := ParseConflictPolicy(tc.input)
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, policy)
+			}
+		})
+	}
+}
+
+func TestConflictPolicyPrecedence(t *testing.T) {
+	file := renderedFile{onConflict: ConflictSkip}
+
+	// 命令行指定的策略优先
+	engine := NewEngine(naming.StyleSnake, WithConflictPolicy(ConflictFail))
+	assert.Equal(t, ConflictFail, engine.conflictPolicy(file))
+
+	// 其次是模板指令中的策略
+	engine = NewEngine(naming.StyleSnake)
+	assert.Equal(t, ConflictSkip, engine.conflictPolicy(file))
+
+	// 默认覆盖
+	assert.Equal(t, ConflictOverwrite, engine.conflictPolicy(renderedFile{}))
+}
+
+func TestConfirmOverwrite(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{"yes", "y\n", true},
+		{"full yes", "Yes\n", true},
+		{"no", "n\n", false},
+		{"empty answer", "\n", false},
+		{"end of input", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			engine := NewEngine(naming.StyleSnake, WithInput(strings.NewReader(tc.input)), WithOutput(&out))
+			ok, err := engine.confirmOverwrite("user_model.go")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, ok)
+			assert.Contains(t, out.String(), "user_model.go already exists, overwrite? [y/N]")
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// directivePattern 匹配模板首行的指令注释，例如 {{/* go-gen: prefix=false */}}
+var directivePattern = regexp.MustCompile(`^\{\{-?\s*/\*\s*go-gen:(.*?)\*/\s*-?\}\}`)
+
+// fileOptions 单个模板文件的生成选项，可以在模板首行通过指令注释设置
+type fileOptions struct {
+	prefix     bool           // 输出文件名是否添加类型名前缀
+	format     bool           // 是否格式化生成的 Go 文件
+	onConflict ConflictPolicy // 输出文件已存在时的默认处理策略
+}
+
+// defaultFileOptions 返回默认的文件生成选项
+func defaultFileOptions() fileOptions {
+	return fileOptions{
+		prefix: true,
+		format: true,
+	}
+}
+
+// parseDirectives 解析模板首行的指令注释
+//
+// 指令以空格分隔的 key=value 形式书写，注释本身不会出现在输出中：
+//
+//	{{/* go-gen: prefix=false format=false on-conflict=skip */}}
+func parseDirectives(content string) (fileOptions, error) {
+	opts := defaultFileOptions()
+
+	match := directivePattern.FindStringSubmatch(content)
+	if match == nil {
+		return opts, nil
+	}
+
+	for _, field := range strings.Fields(match[1]) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return opts, fmt.Errorf("invalid directive %q, expected key=value", field)
+		}
+		switch key {
+		case "prefix", "format":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("invalid directive %q: %w", field, err)
+			}
+			if key == "prefix" {
+				opts.prefix = b
+			} else {
+				opts.format = b
+			}
+		case "on-conflict":
+			policy, err := ParseConflictPolicy(value)
+			if err != nil || policy == "" {
+				return opts, fmt.Errorf("invalid directive %q, expected skip|overwrite|backup|fail|prompt", field)
+			}
+			opts.onConflict = policy
+		default:
+			return opts, fmt.Errorf("unknown directive %q", key)
+		}
+	}
+	return opts, nil
+}
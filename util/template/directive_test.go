@@ -0,0 +1,41 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDirectives(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		expected    fileOptions
+		expectError bool
+	}{
+		{"no directive", "package {{.PackageName}}", fileOptions{prefix: true, format: true}, false},
+		{"prefix disabled", "{{/* go-gen: prefix=false */}}\npackage x", fileOptions{prefix: false, format: true}, false},
+		{"trim markers", "{{- /* go-gen: prefix=false */ -}}\npackage x", fileOptions{prefix: false, format: true}, false},
+		{"format disabled", "{{/* go-gen: prefix=false format=false */}}", fileOptions{prefix: false, format: false}, false},
+		{"not on first line", "package x\n{{/* go-gen: prefix=false */}}", fileOptions{prefix: true, format: true}, false},
+		{"regular comment", "{{/* just a comment */}}", fileOptions{prefix: true, format: true}, false},
+		{"missing value", "{{/* go-gen: prefix */}}", fileOptions{}, true},
+		{"invalid bool", "{{/* go-gen: prefix=maybe */}}", fileOptions{}, true},
+		{"on-conflict", "{{/* go-gen: on-conflict=skip */}}", fileOptions{prefix: true, format: true, onConflict: ConflictSkip}, false},
+		{"invalid on-conflict", "{{/* go-gen: on-conflict=merge */}}", fileOptions{}, true},
+		{"empty on-conflict", "{{/* go-gen: on-conflict= */}}", fileOptions{}, true},
+		{"unknown directive", "{{/* go-gen: color=red */}}", fileOptions{}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts, err := parseDirectives(tc.content)
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, opts)
+			}
+		})
+	}
+}
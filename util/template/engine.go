@@ -1,52 +1,324 @@
 package template
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"text/template"
 
+	builtin "github.com/lewinz/go-gen/template"
+	"github.com/lewinz/go-gen/util/field"
+	"github.com/lewinz/go-gen/util/format"
 	"github.com/lewinz/go-gen/util/naming"
 )
 
 const (
 	cacheDir = ".go-gen"
+
+	// BuiltinTemplate 内置模板的名称，内置模板随二进制文件一起发布，不需要访问网络
+	BuiltinTemplate = "builtin"
 )
 
 // Engine 模板处理引擎
 type Engine struct {
-	fileStyle naming.Style
+	fileStyle  naming.Style
+	generator  string                 // 生成器名称，用于在模板包清单中查找对应的生成器
+	vars       map[string]interface{} // 用户输入的模板变量
+	dryRun     bool                   // 只列出将要变更的文件，不写入
+	diff       bool                   // 输出与已有文件的差异，不写入
+	out        io.Writer              // 预览结果和提示信息的输出位置
+	input      *bufio.Reader          // prompt 策略读取回答的位置
+	onConflict ConflictPolicy         // 输出文件已存在时的处理策略，为空时使用模板中的设置
+	ref        string                 // Git 模板固定使用的 tag、分支或提交，或 Go 模块的版本
+	path       string                 // 模板包在模板目录或仓库中的子目录
+	offline    bool                   // 不访问远程仓库，只使用已缓存的 Git 模板和 Go 模块缓存
+	pkg        string                 // 生成代码的包名，为空时使用输出目录名
+	module     string                 // 项目的模块路径
+	checksum   string                 // 压缩包模板（sha256:<hex>）或 Go 模块模板（h1:<hash>）的期望校验和
+	fields     []field.Field          // 模型的字段
+}
+
+// Option 模板处理引擎的可选配置
+type Option func(*Engine)
+
+// WithGenerator 指定生成器名称
+func WithGenerator(name string) Option {
+	return func(e *Engine) {
+		e.generator = name
+	}
+}
+
+// WithVars 指定模板变量，模板中通过 .Vars 访问
+func WithVars(vars map[string]interface{}) Option {
+	return func(e *Engine) {
+		e.vars = vars
+	}
+}
+
+// WithDryRun 只列出将要创建、修改或保持不变的文件，不写入磁盘
+func WithDryRun(dryRun bool) Option {
+	return func(e *Engine) {
+		e.dryRun = dryRun
+	}
+}
+
+// WithDiff 输出生成结果与已有文件的统一差异格式（unified diff），不写入磁盘
+func WithDiff(diff bool) Option {
+	return func(e *Engine) {
+		e.diff = diff
+	}
+}
+
+// WithOutput 指定预览结果和提示信息的输出位置，默认为标准输出
+func WithOutput(w io.Writer) Option {
+	return func(e *Engine) {
+		e.out = w
+	}
+}
+
+// WithConflictPolicy 指定输出文件已存在且内容不同时的处理策略，优先于模板中的设置
+func WithConflictPolicy(policy ConflictPolicy) Option {
+	return func(e *Engine) {
+		e.onConflict = policy
+	}
+}
+
+// WithInput 指定 prompt 策略读取回答的位置，默认为标准输入
+func WithInput(r io.Reader) Option {
+	return func(e *Engine) {
+		e.input = bufio.NewReader(r)
+	}
+}
+
+// WithRef 指定 Git 模板使用的 tag、分支或提交，为空时使用远程仓库的 HEAD。
+// 对于 Go 模块模板指定模块版本，为空时使用最新版本
+func WithRef(ref string) Option {
+	return func(e *Engine) {
+		e.ref = ref
+	}
+}
+
+// WithPath 指定模板包在模板目录、Git 仓库或 Go 模块中的子目录
+func WithPath(dir string) Option {
+	return func(e *Engine) {
+		e.path = dir
+	}
+}
+
+// WithOffline 不访问远程仓库，只使用已缓存的 Git 模板和 Go 模块缓存
+func WithOffline(offline bool) Option {
+	return func(e *Engine) {
+		e.offline = offline
+	}
+}
+
+// WithChecksum 指定压缩包模板（sha256:<hex>）或 Go 模块模板（h1:<hash>，与 go.sum 中的格式相同）的期望校验和，
+// 不匹配时停止生成
+func WithChecksum(checksum string) Option {
+	return func(e *Engine) {
+		e.checksum = checksum
+	}
+}
+
+// WithPackage 指定生成代码的包名，为空时使用输出目录名。模板子目录中的文件仍使用所在目录名
+func WithPackage(name string) Option {
+	return func(e *Engine) {
+		e.pkg = name
+	}
+}
+
+// WithModule 指定项目的模块路径，模板中通过 .Module 访问，用于生成导入路径
+func WithModule(module string) Option {
+	return func(e *Engine) {
+		e.module = module
+	}
+}
+
+// WithFields 指定模型的字段，模板中通过 .Fields 访问
+func WithFields(fields []field.Field) Option {
+	return func(e *Engine) {
+		e.fields = fields
+	}
 }
 
 // NewEngine 创建一个模板处理引擎
-func NewEngine(fileStyle naming.Style) *Engine {
-	return &Engine{
+func NewEngine(fileStyle naming.Style, opts ...Option) *Engine {
+	e := &Engine{
 		fileStyle: fileStyle,
+		out:       os.Stdout,
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 // TemplateData 模板数据
 type TemplateData struct {
-	Type        string // 模型类型
-	TypeSnake   string // 蛇形命名
-	TypeCamel   string // 驼峰命名
-	TypePascal  string // 帕斯卡命名
-	TypeKebab   string // 短横线命名
-	PackageName string // 包名
+	Type        string                 // 模型类型
+	TypeSnake   string                 // 蛇形命名
+	TypeCamel   string                 // 驼峰命名
+	TypePascal  string                 // 帕斯卡命名
+	TypeKebab   string                 // 短横线命名
+	PackageName string                 // 包名
+	Module      string                 // 项目的模块路径
+	Fields      []field.Field          // 模型的字段
+	Structs     []field.Struct         // 嵌套字段声明的结构体
+	Vars        map[string]interface{} // 模板变量
 }
 
 // Generate 生成代码文件
+//
+// templateDir 可以是内置模板 builtin、本地目录、.tar.gz/.zip 压缩包（本地文件或 URL）或 Git 仓库地址，
+// 只有 Git 仓库、Go 模块和远程压缩包才需要访问网络。
+// Git 仓库地址和 Go 模块路径可以通过 //subdir 指定其中的模板包目录，通过 @ref 固定版本，
+// 例如 https://github.com/org/repo.git//templates@v1.4.0、github.com/org/templates@v1.2.0
 func (e *Engine) Generate(templateDir, outputDir, typeName string) error {
-	// 如果是 git 仓库，先克隆或使用缓存
-	if isGitRepo(templateDir) {
-		cachedDir, err := getCachedTemplate(templateDir)
+	if e.checksum != "" && !isArchive(templateDir) && !isGoModule(templateDir) {
+		return fmt.Errorf("template checksum requires an archive or Go module template")
+	}
+
+	var fsys fs.FS
+	subdir := e.path
+	switch {
+	case isGitRepo(templateDir):
+		// 如果是 git 仓库，先克隆或使用缓存
+		repoURL, ref, repoPath, err := e.parseSource(templateDir)
+		if err != nil {
+			return err
+		}
+		if repoPath != "" {
+			subdir = repoPath
+		}
+		// 指定了模板包目录时只检出该目录
+		var paths []string
+		if subdir != "" {
+			dir, err := cleanSubdir(subdir)
+			if err != nil {
+				return err
+			}
+			if dir != "." {
+				paths = []string{dir}
+			}
+		}
+		cachedDir, lock, err := getCachedTemplate(repoURL, ref, paths, e.offline)
+		var remoteErr *remoteError
+		if errors.As(err, &remoteErr) {
+			// 无法访问远程仓库时回退到已缓存的版本
+			if dir, cacheLock, cacheErr := getCachedTemplate(repoURL, ref, paths, true); cacheErr == nil {
+				fmt.Fprintf(e.out, "warning: %v, using the cached copy of %s\n", err, source(repoURL, ref))
+				cachedDir, lock, err = dir, cacheLock, nil
+			}
+		}
 		if err != nil {
 			return fmt.Errorf("get cached template: %w", err)
 		}
-		templateDir = cachedDir
+		// 生成结束前持有共享锁，避免其他进程更新或删除正在读取的缓存
+		defer lock.unlock()
+		fsys = os.DirFS(cachedDir)
+	case isGoModule(templateDir):
+		// Go 模块通过 go mod download 下载到模块缓存中
+		modPath, version, modSubdir, err := e.parseSource(templateDir)
+		if err != nil {
+			return err
+		}
+		if modSubdir != "" {
+			subdir = modSubdir
+		}
+		dir, err := getModuleTemplate(modPath, version, e.checksum, e.offline)
+		if err != nil {
+			return err
+		}
+		fsys = os.DirFS(dir)
+	case e.ref != "":
+		return fmt.Errorf("template ref %s requires a Git or Go module template", e.ref)
+	case isArchive(templateDir):
+		// 压缩包解压到临时目录，生成结束后删除
+		if e.offline && isRemoteArchive(templateDir) {
+			return fmt.Errorf("template %s cannot be downloaded offline", templateDir)
+		}
+		content, err := readArchive(templateDir, e.checksum)
+		if err != nil {
+			return err
+		}
+		tmpDir, err := os.MkdirTemp("", "go-gen-template-*")
+		if err != nil {
+			return fmt.Errorf("create temp dir: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		if err := extractArchive(templateDir, content, tmpDir); err != nil {
+			return err
+		}
+		fsys = os.DirFS(tmpDir)
+	case templateDir == BuiltinTemplate:
+		// 内置模板直接从二进制文件中读取
+		fsys = builtin.FS
+	default:
+		fsys = os.DirFS(templateDir)
+	}
+
+	// 只使用模板包所在的子目录
+	if subdir != "" {
+		var err error
+		if fsys, err = subFS(fsys, subdir); err != nil {
+			return err
+		}
+	}
+
+	return e.GenerateFS(fsys, outputDir, typeName)
+}
+
+// cleanSubdir 将模板包目录规范化为以 / 分隔的相对路径
+func cleanSubdir(dir string) (string, error) {
+	cleaned := path.Clean(strings.Trim(filepath.ToSlash(dir), "/"))
+	if !fs.ValidPath(cleaned) {
+		return "", fmt.Errorf("invalid template path %q", cleaned)
 	}
+	return cleaned, nil
+}
 
+// parseSource 解析 Git 仓库地址或 Go 模块路径，返回地址、版本和模板包目录
+//
+// 地址中的 @ref 和 //subdir 与 WithRef、WithPath 指定的值冲突时报错
+func (e *Engine) parseSource(templateDir string) (string, string, string, error) {
+	source, ref, subdir := parseGitSource(templateDir)
+	if e.ref != "" && ref != "" && e.ref != ref {
+		return "", "", "", fmt.Errorf("template ref specified twice: %s and %s", ref, e.ref)
+	}
+	if ref == "" {
+		ref = e.ref
+	}
+	if e.path != "" && subdir != "" && path.Clean(e.path) != path.Clean(subdir) {
+		return "", "", "", fmt.Errorf("template path specified twice: %s and %s", subdir, e.path)
+	}
+	return source, ref, subdir, nil
+}
+
+// subFS 返回 fsys 中子目录 dir 对应的文件系统
+func subFS(fsys fs.FS, dir string) (fs.FS, error) {
+	dir, err := cleanSubdir(dir)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fs.Stat(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("template path %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("template path %s is not a directory", dir)
+	}
+	return fs.Sub(fsys, dir)
+}
+
+// GenerateFS 使用文件系统 fsys 中的模板生成代码文件，fsys 的根目录即模板包根目录
+func (e *Engine) GenerateFS(fsys fs.FS, outputDir, typeName string) error {
 	// 准备模板数据
 	data := &TemplateData{
 		Type:        typeName,
@@ -54,127 +326,309 @@ func (e *Engine) Generate(templateDir, outputDir, typeName string) error {
 		TypeCamel:   naming.NewConverter(naming.StyleCamel).Convert(typeName),
 		TypePascal:  naming.NewConverter(naming.StylePascal).Convert(typeName),
 		TypeKebab:   naming.NewConverter(naming.StyleKebab).Convert(typeName),
-		PackageName: filepath.Base(outputDir),
+		PackageName: packageName(filepath.Base(outputDir)),
+		Module:      e.module,
+		Fields:      e.fields,
+		Structs:     field.Structs(e.fields),
+		Vars:        e.vars,
+	}
+	if e.pkg != "" {
+		data.PackageName = e.pkg
 	}
 
-	// 遍历模板目录
-	return filepath.Walk(templateDir, func(path string, info os.FileInfo, err error) error {
+	// 读取模板包清单，在渲染前校验版本、生成器和变量
+	manifest, err := LoadManifestFS(fsys)
+	if err != nil {
+		return err
+	}
+	walkDir := "."
+	if manifest != nil {
+		if walkDir, data.Vars, err = e.applyManifest(manifest, fsys); err != nil {
+			return err
+		}
+	}
+
+	// 清单没有指定生成器目录时，默认使用与生成器同名的子目录，例如 mongo/
+	if walkDir == "." && e.generator != "" {
+		if info, err := fs.Stat(fsys, e.generator); err == nil && info.IsDir() {
+			walkDir = e.generator
+		}
+	}
+
+	// 加载公共片段
+	partials, err := loadPartials(fsys, walkDir)
+	if err != nil {
+		return fmt.Errorf("load partials: %w", err)
+	}
+
+	// 遍历模板目录，全部渲染成功后再统一写入，避免留下生成了一半的结果
+	var files []renderedFile
+	outputs := make(map[string]string) // 输出路径到模板名，避免两个模板生成同一个文件
+	err = fs.WalkDir(fsys, walkDir, func(name string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// 跳过目录
-		if info.IsDir() {
+		// 跳过目录，公共片段目录不再深入
+		if d.IsDir() {
+			if d.Name() == partialDir {
+				return fs.SkipDir
+			}
 			return nil
 		}
 
-		// 只处理 .tpl 文件
-		if !strings.HasSuffix(info.Name(), ".tpl") {
+		// 只处理 .tpl 文件，公共片段不生成输出
+		if !strings.HasSuffix(d.Name(), ".tpl") || isPartial(d.Name()) {
 			return nil
 		}
 
-		// 读取模板文件
-		tmpl, err := template.ParseFiles(path)
+		// 读取模板文件，在公共片段集合的副本中解析
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("read template %s: %w", name, err)
+		}
+		opts, err := parseDirectives(string(content))
 		if err != nil {
-			return fmt.Errorf("parse template %s: %w", path, err)
+			return fmt.Errorf("parse template %s: %w", name, err)
+		}
+		set, err := partials.Clone()
+		if err != nil {
+			return fmt.Errorf("clone partials: %w", err)
+		}
+		tmpl, err := set.New(d.Name()).Parse(string(content))
+		if err != nil {
+			return fmt.Errorf("parse template %s: %w", name, err)
 		}
 
-		// 生成输出文件名
-		outputName := strings.TrimSuffix(info.Name(), ".tpl") // 去掉 .tpl 后缀
-		// 组合类型名和模板名，并确保它们之间有分隔符
-		outputName = typeName + "_" + outputName // 例如：user + _ + model = user_model
-		// 根据指定的命名风格转换
-		converter := naming.NewConverter(e.fileStyle)
-		outputName = converter.Convert(outputName) // 例如：user_model -> userModel
-		outputName = outputName + ".go"            // 添加 .go 后缀
+		// 生成输出路径，清单中声明的输出路径优先
+		var outputName string
+		if file := manifestFile(manifest, name); file != nil {
+			if outputName, err = renderString(file.Output, data); err == nil {
+				outputName, err = cleanOutputName(outputName)
+			}
+		} else {
+			relPath := name
+			if walkDir != "." {
+				relPath = strings.TrimPrefix(name, walkDir+"/")
+			}
+			outputName, err = e.outputName(relPath, data, opts)
+		}
+		if err != nil {
+			return fmt.Errorf("output path of %s: %w", name, err)
+		}
 		outputPath := filepath.Join(outputDir, outputName)
+		if other, ok := outputs[outputPath]; ok {
+			return fmt.Errorf("templates %s and %s both generate %s", other, name, outputName)
+		}
+		outputs[outputPath] = name
 
-		// 创建输出文件
-		outputFile, err := os.Create(outputPath)
-		if err != nil {
-			return fmt.Errorf("create output file %s: %w", outputPath, err)
+		// 保留子目录结构，子目录中的文件使用所在目录名转换成的包名
+		fileData := *data
+		if dir := filepath.Dir(outputName); dir != "." {
+			fileData.PackageName = packageName(filepath.Base(dir))
 		}
-		defer outputFile.Close()
 
 		// 渲染模板
-		if err := tmpl.Execute(outputFile, data); err != nil {
-			return fmt.Errorf("execute template %s: %w", path, err)
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, &fileData); err != nil {
+			return fmt.Errorf("execute template %s: %w", name, err)
+		}
+		output := buf.Bytes()
+
+		// 保留已有文件中受保护区域的内容，在格式化前合并以便补全区域中用到的 import
+		if existing, err := os.ReadFile(outputPath); err == nil {
+			merged, dropped, err := mergeRegions(output, existing)
+			if err != nil {
+				return fmt.Errorf("merge regions of %s: %w", outputPath, err)
+			}
+			for _, region := range dropped {
+				fmt.Fprintf(e.out, "warning: region %q of %s no longer exists in the template, its content is dropped\n", region, outputPath)
+			}
+			output = merged
 		}
 
+		// 格式化 Go 文件并修正 import
+		if opts.format && filepath.Ext(outputPath) == ".go" && len(bytes.TrimSpace(output)) > 0 {
+			if output, err = format.Source(output); err != nil {
+				return fmt.Errorf("format %s: %w", name, err)
+			}
+		}
+
+		files = append(files, renderedFile{path: outputPath, content: output, onConflict: opts.onConflict})
 		return nil
 	})
-}
+	if err != nil {
+		return err
+	}
 
-// isGitRepo checks if the path is a git repository URL
-func isGitRepo(path string) bool {
-	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "git@")
+	return e.writeFiles(outputDir, files)
 }
 
-// getRepoHash gets the latest commit hash of a repository
-func getRepoHash(repoURL string) (string, error) {
-	cmd := defaultCommander.Command("git", "ls-remote", repoURL, "HEAD")
-	output, err := cmd.Output()
+// applyManifest 按清单校验生成参数，返回需要遍历的模板目录和补全默认值后的变量
+func (e *Engine) applyManifest(manifest *Manifest, fsys fs.FS) (string, map[string]interface{}, error) {
+	if err := manifest.CheckVersion(Version); err != nil {
+		return "", nil, err
+	}
+
+	walkDir := "."
+	generator, err := manifest.Generator(e.generator)
 	if err != nil {
-		return "", fmt.Errorf("git ls-remote: %w", err)
+		return "", nil, err
 	}
-	parts := strings.Fields(string(output))
-	if len(parts) < 1 {
-		return "", fmt.Errorf("invalid git ls-remote output")
+	if generator != nil && generator.Path != "" {
+		walkDir = path.Clean(generator.Path)
 	}
-	return parts[0], nil
-}
 
-// getCurrentHash gets the current commit hash of a repository
-func getCurrentHash(repoPath string) (string, error) {
-	cmd := defaultCommander.Command("git", "rev-parse", "HEAD")
-	cmd.Dir = repoPath
-	output, err := cmd.Output()
+	for _, file := range manifest.Files {
+		if _, err := fs.Stat(fsys, path.Clean(file.Template)); err != nil {
+			return "", nil, fmt.Errorf("template pack %s: file %s: %w", manifest.Name, file.Template, err)
+		}
+	}
+
+	vars, err := manifest.ResolveVars(e.vars)
 	if err != nil {
-		return "", fmt.Errorf("git rev-parse: %w", err)
+		return "", nil, fmt.Errorf("template pack %s: %w", manifest.Name, err)
 	}
-	return strings.TrimSpace(string(output)), nil
+	return walkDir, vars, nil
 }
 
-// getCachedTemplate gets or creates a cached template
-func getCachedTemplate(repoURL string) (string, error) {
-	// Get home directory
-	homeDir, err := os.UserHomeDir()
+// manifestFile 查找模板文件在清单中的条目，没有清单时返回 nil
+func manifestFile(manifest *Manifest, relPath string) *ManifestFile {
+	if manifest == nil {
+		return nil
+	}
+	return manifest.File(relPath)
+}
+
+// renderString 使用默认函数集合渲染模板字符串
+func renderString(text string, data interface{}) (string, error) {
+	tmpl, err := template.New("").Funcs(FuncMap()).Parse(text)
 	if err != nil {
-		return "", fmt.Errorf("get home dir: %w", err)
+		return "", err
 	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
 
-	// Create cache directory if not exists
-	cachePath := filepath.Join(homeDir, cacheDir)
-	if err := os.MkdirAll(cachePath, 0755); err != nil {
-		return "", fmt.Errorf("create cache dir: %w", err)
+// isGitRepo checks if the path is a git repository URL
+func isGitRepo(path string) bool {
+	if isArchive(path) {
+		return false
+	}
+	for _, prefix := range []string{"http://", "https://", "ssh://", "git://", "file://", "git@"} {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
 	}
+	return false
+}
 
-	// Get repository name and hash
-	repoName := getRepoName(repoURL)
-	repoHash, err := getRepoHash(repoURL)
-	if err != nil {
-		return "", fmt.Errorf("get repo hash: %w", err)
+// parseGitSource splits a Git template source into the repository URL, the ref and
+// the template pack directory inside the repository, e.g.
+// https://host/org/repo.git//templates@v1.4.0 or https://host/org/repo.git@v1.4.0//templates
+func parseGitSource(source string) (string, string, string) {
+	repoURL, ref := splitRef(source)
+	repoURL, subdir := splitSubdir(repoURL)
+	if ref == "" {
+		repoURL, ref = splitRef(repoURL)
 	}
+	return repoURL, ref, subdir
+}
 
-	// Check if cached version exists
-	cachedPath := filepath.Join(cachePath, repoName)
-	if _, err := os.Stat(cachedPath); err == nil {
-		// Check if hash matches
-		currentHash, err := getCurrentHash(cachedPath)
-		if err == nil && currentHash == repoHash {
-			return cachedPath, nil
+// splitSubdir splits an optional "//subdir" suffix off a repository URL
+func splitSubdir(source string) (string, string) {
+	start := 0
+	if i := strings.Index(source, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	i := strings.Index(source[start:], "//")
+	if i < 0 {
+		return source, ""
+	}
+	return source[:start+i], strings.Trim(source[start+i+2:], "/")
+}
+
+// splitRef splits an optional "@ref" suffix off a repository URL,
+// e.g. https://host/org/repo@v1.4.0. The "@" of git@host:org/repo is not a ref
+func splitRef(source string) (string, string) {
+	i := strings.LastIndex(source, "@")
+	if i < 0 {
+		return source, ""
+	}
+	ref := source[i+1:]
+	if ref == "" || strings.ContainsAny(ref, "/:") {
+		return source, ""
+	}
+	return source[:i], ref
+}
+
+// isCommitHash checks if ref is a full commit hash
+func isCommitHash(ref string) bool {
+	return len(ref) == 40 && isHex(ref)
+}
+
+// isHex checks if s is a non-empty lowercase hexadecimal string
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
 		}
-		// Remove old cache if hash doesn't match
-		os.RemoveAll(cachedPath)
 	}
+	return true
+}
 
-	// Clone repository
-	cmd := defaultCommander.Command("git", "clone", repoURL, cachedPath)
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("git clone: %w", err)
+// getRepoHash gets the commit hash a ref of a repository points to, HEAD if ref is empty.
+// It returns an empty hash if the remote has no such ref
+func getRepoHash(repoURL, ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	cmd := defaultCommander.Command("git", "ls-remote", repoURL, ref)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git ls-remote: %w", err)
+	}
+	if ref == "HEAD" {
+		parts := strings.Fields(string(output))
+		if len(parts) < 1 {
+			return "", fmt.Errorf("invalid git ls-remote output")
+		}
+		return parts[0], nil
+	}
+
+	// Annotated tags are listed twice, the peeled "^{}" entry is the commit
+	var hash string
+	for _, line := range strings.Split(string(output), "\n") {
+		parts := strings.Fields(line)
+		if len(parts) < 2 {
+			continue
+		}
+		name := strings.TrimSuffix(parts[1], "^{}")
+		if name != ref && name != "refs/heads/"+ref && name != "refs/tags/"+ref {
+			continue
+		}
+		if hash == "" || strings.HasSuffix(parts[1], "^{}") {
+			hash = parts[0]
+		}
 	}
+	return hash, nil
+}
 
-	return cachedPath, nil
+// getCurrentHash gets the current commit hash of a repository
+func getCurrentHash(repoPath string) (string, error) {
+	cmd := defaultCommander.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = repoPath
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
 }
 
 // getRepoName extracts repository name from URL
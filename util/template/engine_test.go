@@ -1,20 +1,32 @@
 package template
 
 import (
+	"bytes"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
 
+	"github.com/lewinz/go-gen/util/field"
 	"github.com/lewinz/go-gen/util/naming"
 	"github.com/stretchr/testify/assert"
 )
 
 // MockCommander 实现命令执行器接口用于测试
-type MockCommander struct{}
+type MockCommander struct {
+	mu    sync.Mutex
+	calls [][]string // 记录执行过的命令
+}
 
 // Command 返回一个模拟的命令
 func (c *MockCommander) Command(name string, args ...string) *exec.Cmd {
+	c.mu.Lock()
+	c.calls = append(c.calls, append([]string{name}, args...))
+	c.mu.Unlock()
 	cs := []string{"-test.run=TestHelperProcess", "--", name}
 	cs = append(cs, args...)
 	cmd := exec.Command(os.Args[0], cs...)
@@ -44,15 +56,32 @@ func TestHelperProcess(t *testing.T) {
 		switch args[1] {
 		case "ls-remote":
 			// 检查是否是有效的仓库 URL
-			if args[2] == "invalid-url" || args[2] == "" {
+			if args[2] == "invalid-url" || args[2] == "" || strings.Contains(args[2], "unreachable") {
 				os.Exit(1)
 			}
-			os.Stdout.Write([]byte("abcdef1234567890 HEAD"))
+			switch args[3] {
+			case "HEAD":
+				os.Stdout.Write([]byte("abcdef1234567890 HEAD"))
+			case "v1.4.0":
+				// 附注标签会同时列出标签对象和它指向的提交
+				os.Stdout.Write([]byte("1111111111111111\trefs/tags/v1.4.0\nabcdef1234567890\trefs/tags/v1.4.0^{}\n"))
+			case "main":
+				os.Stdout.Write([]byte("2222222222222222\trefs/heads/main\n"))
+			}
 		case "rev-parse":
 			os.Stdout.Write([]byte("abcdef1234567890"))
-		case "clone":
-			// 模拟克隆成功
 		}
+	case "go":
+		// go mod download -json <module>@<version>
+		modPath, version, _ := strings.Cut(args[len(args)-1], "@")
+		if strings.Contains(modPath, "missing") {
+			fmt.Fprintf(os.Stdout, `{"Path": %q, "Version": %q, "Error": "not found"}`, modPath, version)
+			os.Exit(1)
+		}
+		if version == "latest" {
+			version = "v1.2.0"
+		}
+		fmt.Fprintf(os.Stdout, `{"Path": %q, "Version": %q, "Dir": "/modcache/%s@%s", "Sum": "h1:abc="}`, modPath, version, modPath, version)
 	}
 	os.Exit(0)
 }
@@ -159,6 +188,10 @@ func TestIsGitRepo(t *testing.T) {
 		{"http", "http://github.com/user/repo", true},
 		{"https", "https://github.com/user/repo", true},
 		{"ssh", "git@github.com:user/repo", true},
+		{"ssh url", "ssh://git@github.com/user/repo.git", true},
+		{"git protocol", "git://github.com/user/repo.git", true},
+		{"file url", "file:///srv/git/repo.git", true},
+		{"archive url", "https://example.com/templates.tar.gz", false},
 		{"local", "/path/to/repo", false},
 		{"relative", "./repo", false},
 	}
@@ -208,6 +241,8 @@ func TestGetCachedTemplate(t *testing.T) {
 	originalHome := os.Getenv("HOME")
 	os.Setenv("HOME", homeDir)
 	defer os.Setenv("HOME", originalHome)
+	t.Setenv("GO_GEN_CACHE", "")
+	t.Setenv("XDG_CACHE_HOME", "")
 
 	testCases := []struct {
 		name        string
@@ -245,7 +280,7 @@ func TestGetCachedTemplate(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			if tc.setupCache {
 				// 创建缓存目录
-				cacheDir := filepath.Join(homeDir, ".go-gen", "repo")
+				cacheDir := filepath.Join(homeDir, ".go-gen", cacheKey(tc.repoURL, ""))
 				err := os.MkdirAll(cacheDir, 0755)
 				assert.NoError(t, err)
 
@@ -260,17 +295,252 @@ func TestGetCachedTemplate(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
-			result, err := getCachedTemplate(tc.repoURL)
+			result, lock, err := getCachedTemplate(tc.repoURL, "", nil, false)
 			if tc.expectError {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
 				assert.NotEmpty(t, result)
+				assert.NoError(t, lock.unlock())
+			}
+		})
+	}
+}
+
+func TestSplitRef(t *testing.T) {
+	testCases := []struct {
+		name        string
+		source      string
+		expectedURL string
+		expectedRef string
+	}{
+		{"https without ref", "https://github.com/user/repo", "https://github.com/user/repo", ""},
+		{"https with tag", "https://github.com/user/repo@v1.4.0", "https://github.com/user/repo", "v1.4.0"},
+		{"https with commit", "https://github.com/user/repo.git@abcdef1", "https://github.com/user/repo.git", "abcdef1"},
+		{"ssh without ref", "git@github.com:user/repo.git", "git@github.com:user/repo.git", ""},
+		{"ssh with branch", "git@github.com:user/repo.git@main", "git@github.com:user/repo.git", "main"},
+		{"userinfo is not a ref", "https://token@github.com/user/repo", "https://token@github.com/user/repo", ""},
+		{"empty ref", "https://github.com/user/repo@", "https://github.com/user/repo@", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url, ref := splitRef(tc.source)
+			assert.Equal(t, tc.expectedURL, url)
+			assert.Equal(t, tc.expectedRef, ref)
+		})
+	}
+}
+
+func TestGetRepoHash(t *testing.T) {
+	// 保存原始的命令执行器
+	oldCommander := defaultCommander
+	defer func() { defaultCommander = oldCommander }()
+
+	// 替换为 mock 版本
+	defaultCommander = &MockCommander{}
+
+	testCases := []struct {
+		name     string
+		ref      string
+		expected string
+	}{
+		{"head", "", "abcdef1234567890"},
+		{"annotated tag", "v1.4.0", "abcdef1234567890"},
+		{"branch", "main", "2222222222222222"},
+		{"unknown ref", "abcdef1", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hash, err := getRepoHash("https://github.com/user/repo", tc.ref)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, hash)
+		})
+	}
+}
+
+func TestGetCachedTemplateWithRef(t *testing.T) {
+	// 保存原始的命令执行器
+	oldCommander := defaultCommander
+	defer func() { defaultCommander = oldCommander }()
+
+	// 设置临时 home 目录
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("GO_GEN_CACHE", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+
+	const repoURL = "https://github.com/user/repo"
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+
+	testCases := []struct {
+		name          string
+		ref           string
+		paths         []string
+		setupCache    bool
+		cachedPaths   []string
+		expectedPath  string
+		expectedCalls [][]string
+		expectError   bool
+	}{
+		{
+			name:         "tag",
+			ref:          "v1.4.0",
+			expectedPath: filepath.Join(homeDir, ".go-gen", cacheKey(repoURL, "v1.4.0")),
+			expectedCalls: [][]string{
+				{"git", "ls-remote", repoURL, "v1.4.0"},
+				{"git", "init", "-q"},
+				{"git", "remote", "add", "origin", repoURL},
+				{"git", "fetch", "-q", "--depth", "1", "--filter=blob:none", "origin", "abcdef1234567890"},
+				{"git", "checkout", "-q", "--force", "--detach", "FETCH_HEAD"},
+			},
+		},
+		{
+			name:         "cached tag",
+			ref:          "v1.4.0",
+			setupCache:   true,
+			expectedPath: filepath.Join(homeDir, ".go-gen", cacheKey(repoURL, "v1.4.0")),
+			expectedCalls: [][]string{
+				{"git", "ls-remote", repoURL, "v1.4.0"},
+				{"git", "rev-parse", "HEAD"},
+			},
+		},
+		{
+			name:         "full commit hash",
+			ref:          commit,
+			expectedPath: filepath.Join(homeDir, ".go-gen", cacheKey(repoURL, commit)),
+			expectedCalls: [][]string{
+				{"git", "init", "-q"},
+				{"git", "remote", "add", "origin", repoURL},
+				{"git", "fetch", "-q", "--depth", "1", "--filter=blob:none", "origin", commit},
+				{"git", "checkout", "-q", "--force", "--detach", "FETCH_HEAD"},
+			},
+		},
+		{
+			name:         "abbreviated commit hash",
+			ref:          "abcdef1",
+			expectedPath: filepath.Join(homeDir, ".go-gen", cacheKey(repoURL, "abcdef1")),
+			expectedCalls: [][]string{
+				{"git", "ls-remote", repoURL, "abcdef1"},
+				{"git", "init", "-q"},
+				{"git", "remote", "add", "origin", repoURL},
+				{"git", "fetch", "-q", "--filter=blob:none", "--tags", "origin"},
+				{"git", "checkout", "-q", "--force", "--detach", "abcdef1"},
+				{"git", "rev-parse", "HEAD"},
+			},
+		},
+		{
+			name:         "sparse checkout",
+			ref:          "abcdef1234567890abcdef1234567890abcdef12",
+			paths:        []string{"templates/mongo"},
+			expectedPath: filepath.Join(homeDir, ".go-gen", cacheKey(repoURL, "abcdef1234567890abcdef1234567890abcdef12")),
+			expectedCalls: [][]string{
+				{"git", "init", "-q"},
+				{"git", "remote", "add", "origin", repoURL},
+				{"git", "sparse-checkout", "set", "--cone", "--", "templates/mongo"},
+				{"git", "fetch", "-q", "--depth", "1", "--filter=blob:none", "origin", "abcdef1234567890abcdef1234567890abcdef12"},
+				{"git", "checkout", "-q", "--force", "--detach", "FETCH_HEAD"},
+			},
+		},
+		{
+			name:         "branch moved",
+			ref:          "main",
+			setupCache:   true,
+			expectedPath: filepath.Join(homeDir, ".go-gen", cacheKey(repoURL, "main")),
+			expectedCalls: [][]string{
+				{"git", "ls-remote", repoURL, "main"},
+				{"git", "rev-parse", "HEAD"},
+				{"git", "rev-parse", "HEAD"}, // 获取独占锁后再次检查
+				{"git", "fetch", "-q", "--depth", "1", "--filter=blob:none", "origin", "2222222222222222"},
+				{"git", "checkout", "-q", "--force", "--detach", "FETCH_HEAD"},
+				{"git", "rev-parse", "HEAD"},
+			},
+		},
+		{
+			name:         "path not checked out",
+			ref:          "",
+			paths:        []string{"templates/mysql"},
+			setupCache:   true,
+			cachedPaths:  []string{"templates/mongo"},
+			expectedPath: filepath.Join(homeDir, ".go-gen", cacheKey(repoURL, "")),
+			expectedCalls: [][]string{
+				{"git", "ls-remote", repoURL, "HEAD"},
+				{"git", "rev-parse", "HEAD"},
+				{"git", "rev-parse", "HEAD"}, // 获取独占锁后再次检查
+				{"git", "sparse-checkout", "set", "--cone", "--", "templates/mongo", "templates/mysql"},
+				{"git", "fetch", "-q", "--depth", "1", "--filter=blob:none", "origin", "abcdef1234567890"},
+				{"git", "checkout", "-q", "--force", "--detach", "FETCH_HEAD"},
+				{"git", "rev-parse", "HEAD"},
+			},
+		},
+		{
+			name:        "unknown ref",
+			ref:         "v9.9.9",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.setupCache {
+				err := os.MkdirAll(filepath.Join(tc.expectedPath, ".git"), 0755)
+				assert.NoError(t, err)
+				entry := &CacheEntry{
+					Key:   filepath.Base(tc.expectedPath),
+					Path:  tc.expectedPath,
+					URL:   repoURL,
+					Ref:   tc.ref,
+					Paths: tc.cachedPaths,
+				}
+				assert.NoError(t, entry.save())
+			}
+
+			commander := &MockCommander{}
+			defaultCommander = commander
+			result, lock, err := getCachedTemplate(repoURL, tc.ref, tc.paths, false)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, lock.unlock())
+			assert.Equal(t, tc.expectedPath, result)
+			assert.Equal(t, tc.expectedCalls, commander.calls)
+
+			// 检出的目录记录在缓存条目中
+			entry, err := loadCacheEntry(filepath.Dir(tc.expectedPath), filepath.Base(tc.expectedPath))
+			assert.NoError(t, err)
+			if tc.cachedPaths != nil {
+				assert.Equal(t, append(tc.cachedPaths, tc.paths...), entry.Paths)
+			} else if !tc.setupCache {
+				assert.Equal(t, tc.paths, entry.Paths)
 			}
 		})
 	}
 }
 
+func TestGenerateWithRef(t *testing.T) {
+	// 保存原始的命令执行器
+	oldCommander := defaultCommander
+	defer func() { defaultCommander = oldCommander }()
+	defaultCommander = &MockCommander{}
+
+	t.Setenv("GO_GEN_CACHE", t.TempDir())
+	outputDir := t.TempDir()
+
+	// 同时通过 @ref 和 WithRef 指定不同的版本
+	engine := NewEngine(naming.StyleSnake, WithRef("main"))
+	err := engine.Generate("https://github.com/user/repo@v1.4.0", outputDir, "user")
+	assert.ErrorContains(t, err, "template ref specified twice")
+
+	// 本地模板不支持指定版本
+	err = engine.Generate(t.TempDir(), outputDir, "user")
+	assert.ErrorContains(t, err, "requires a Git or Go module template")
+	err = engine.Generate(BuiltinTemplate, outputDir, "user")
+	assert.ErrorContains(t, err, "requires a Git or Go module template")
+}
+
 func TestGenerateWithGitTemplate(t *testing.T) {
 	// 保存原始的命令执行器
 	oldCommander := defaultCommander
@@ -298,9 +568,11 @@ func TestGenerateWithGitTemplate(t *testing.T) {
 	originalHome := os.Getenv("HOME")
 	os.Setenv("HOME", homeDir)
 	defer os.Setenv("HOME", originalHome)
+	t.Setenv("GO_GEN_CACHE", "")
+	t.Setenv("XDG_CACHE_HOME", "")
 
 	// 创建缓存目录结构
-	cacheDir := filepath.Join(homeDir, ".go-gen", "repo")
+	cacheDir := filepath.Join(homeDir, ".go-gen", cacheKey("https://github.com/user/repo", ""))
 	err = os.MkdirAll(cacheDir, 0755)
 	assert.NoError(t, err)
 
@@ -397,3 +669,1042 @@ func TestGenerateWithEmptyTemplate(t *testing.T) {
 	_, err = os.Stat(outputFile)
 	assert.NoError(t, err)
 }
+
+func TestGenerateWithFuncMap(t *testing.T) {
+	// 创建临时目录
+	tempDir := t.TempDir()
+
+	// 创建使用模板函数的模板文件
+	templateDir := filepath.Join(tempDir, "template")
+	err := os.MkdirAll(templateDir, 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(templateDir, "model.tpl"), []byte(`package {{.PackageName}}
+
+const collection = {{.Type | snake | plural | quote}}
+`), 0644)
+	assert.NoError(t, err)
+
+	// 测试生成
+	outputDir := filepath.Join(tempDir, "output")
+	err = os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+	engine := NewEngine(naming.StyleSnake)
+	err = engine.Generate(templateDir, outputDir, "UserProfile")
+	assert.NoError(t, err)
+
+	// 验证文件内容
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_profile_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `const collection = "user_profiles"`)
+}
+
+func TestGenerateWithPartials(t *testing.T) {
+	// 创建临时目录
+	tempDir := t.TempDir()
+
+	// 创建模板目录和公共片段
+	templateDir := filepath.Join(tempDir, "template")
+	err := os.MkdirAll(filepath.Join(templateDir, "_partials"), 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(templateDir, "_partials", "header.tpl"),
+		[]byte(`{{define "header"}}// Code generated by go-gen. DO NOT EDIT.{{end}}`), 0644)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(templateDir, "imports.partial.tpl"),
+		[]byte(`{{define "imports"}}import "context"
+
+var _ context.Context{{end}}`), 0644)
+	assert.NoError(t, err)
+
+	// 两个模板共享同一个片段
+	for _, name := range []string{"model.tpl", "repo.tpl"} {
+		err = os.WriteFile(filepath.Join(templateDir, name), []byte(`{{template "header" .}}
+package {{.PackageName}}
+
+{{template "imports" .}}
+`), 0644)
+		assert.NoError(t, err)
+	}
+
+	// 测试生成
+	outputDir := filepath.Join(tempDir, "output")
+	err = os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+	engine := NewEngine(naming.StyleSnake)
+	err = engine.Generate(templateDir, outputDir, "user")
+	assert.NoError(t, err)
+
+	// 验证文件内容
+	for _, name := range []string{"user_model.go", "user_repo.go"} {
+		content, err := os.ReadFile(filepath.Join(outputDir, name))
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "// Code generated by go-gen. DO NOT EDIT.")
+		assert.Contains(t, string(content), `import "context"`)
+	}
+
+	// 公共片段不生成输出文件
+	entries, err := os.ReadDir(outputDir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestGenerateWithGeneratorPartials(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// 两个生成器定义同名片段，各自只使用自己的片段
+	templateDir := filepath.Join(tempDir, "template")
+	files := map[string]string{
+		"mongo/_partials/h.tpl": `{{define "header"}}// MONGO header{{end}}`,
+		"mysql/_partials/h.tpl": `{{define "header"}}// MYSQL header{{end}}`,
+		"mongo/model.tpl":       "{{template \"header\"}}\npackage {{.PackageName}}\n",
+		"mysql/model.tpl":       "{{template \"header\"}}\npackage {{.PackageName}}\n",
+	}
+	for name, content := range files {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(templateDir, name)), 0755)
+		assert.NoError(t, err)
+		err = os.WriteFile(filepath.Join(templateDir, name), []byte(content), 0644)
+		assert.NoError(t, err)
+	}
+
+	for _, generator := range []string{"mongo", "mysql"} {
+		outputDir := filepath.Join(tempDir, generator)
+		err := os.MkdirAll(outputDir, 0755)
+		assert.NoError(t, err)
+		engine := NewEngine(naming.StyleSnake, WithGenerator(generator))
+		err = engine.Generate(templateDir, outputDir, "user")
+		assert.NoError(t, err)
+		content, err := os.ReadFile(filepath.Join(outputDir, "user_model.go"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "// "+strings.ToUpper(generator)+" header")
+	}
+}
+
+func TestGenerateWithManifest(t *testing.T) {
+	// 创建临时目录
+	tempDir := t.TempDir()
+
+	// 创建带清单的模板包
+	templateDir := filepath.Join(tempDir, "template")
+	err := os.MkdirAll(filepath.Join(templateDir, "mongo"), 0755)
+	assert.NoError(t, err)
+	writeManifest(t, templateDir, `name: acme
+generators:
+  - name: mongo
+    path: mongo
+variables:
+  - name: collection
+    default: profiles
+files:
+  - template: mongo/model.tpl
+    output: "{{.TypeSnake}}.go"
+`)
+	err = os.WriteFile(filepath.Join(templateDir, "mongo", "model.tpl"),
+		[]byte(`package {{.PackageName}} // {{.Vars.collection}}`), 0644)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(templateDir, "unused.tpl"), []byte(`unused`), 0644)
+	assert.NoError(t, err)
+
+	outputDir := filepath.Join(tempDir, "output")
+	err = os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	// 只渲染生成器目录下的模板，并使用清单中的输出路径
+	engine := NewEngine(naming.StyleSnake, WithGenerator("mongo"))
+	err = engine.Generate(templateDir, outputDir, "UserProfile")
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_profile.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package output // profiles\n", string(content))
+
+	entries, err := os.ReadDir(outputDir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// 清单中没有声明的生成器
+	engine = NewEngine(naming.StyleSnake, WithGenerator("mysql"))
+	err = engine.Generate(templateDir, outputDir, "user")
+	assert.Error(t, err)
+}
+
+func TestGenerateWithManifestValidation(t *testing.T) {
+	// 创建临时目录
+	tempDir := t.TempDir()
+
+	templateDir := filepath.Join(tempDir, "template")
+	err := os.MkdirAll(templateDir, 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(templateDir, "model.tpl"), []byte(`package {{.Vars.collection}}`), 0644)
+	assert.NoError(t, err)
+	outputDir := filepath.Join(tempDir, "output")
+	err = os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	// 缺少必填变量时在渲染前失败
+	writeManifest(t, templateDir, `name: acme
+variables:
+  - name: collection
+    required: true
+`)
+	engine := NewEngine(naming.StyleSnake)
+	err = engine.Generate(templateDir, outputDir, "user")
+	assert.ErrorContains(t, err, `missing required variable "collection"`)
+	_, err = os.Stat(filepath.Join(outputDir, "user_model.go"))
+	assert.True(t, os.IsNotExist(err))
+
+	// 提供变量后生成成功
+	engine = NewEngine(naming.StyleSnake, WithVars(map[string]interface{}{"collection": "users"}))
+	err = engine.Generate(templateDir, outputDir, "user")
+	assert.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_model.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package users\n", string(content))
+
+	// 清单中声明的模板文件不存在
+	writeManifest(t, templateDir, `name: acme
+files:
+  - template: missing.tpl
+    output: missing.go
+`)
+	err = engine.Generate(templateDir, outputDir, "user")
+	assert.Error(t, err)
+
+	// 版本不满足要求
+	oldVersion := Version
+	defer func() { Version = oldVersion }()
+	Version = "v0.1.0"
+	writeManifest(t, templateDir, "name: acme\nminVersion: v1.0.0\n")
+	err = engine.Generate(templateDir, outputDir, "user")
+	assert.ErrorContains(t, err, "requires go-gen >= v1.0.0")
+}
+
+func TestGenerateWithDirectoryStructure(t *testing.T) {
+	// 创建临时目录
+	tempDir := t.TempDir()
+
+	// 创建多包模板结构
+	templateDir := filepath.Join(tempDir, "template")
+	files := map[string]string{
+		"model.tpl":                           `package {{.PackageName}}`,
+		"internal/{{.TypeSnake}}/repo.tpl":    `package {{.PackageName}}`,
+		"internal/service/{{.TypeSnake}}.tpl": `package {{.PackageName}} // {{.TypePascal}}Service`,
+		"{{.TypeKebab}}/handler.tpl":          `package {{.PackageName}}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(templateDir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		assert.NoError(t, err)
+		err = os.WriteFile(path, []byte(content), 0644)
+		assert.NoError(t, err)
+	}
+
+	outputDir := filepath.Join(tempDir, "output")
+	err := os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	engine := NewEngine(naming.StyleSnake)
+	err = engine.Generate(templateDir, outputDir, "user")
+	assert.NoError(t, err)
+
+	// 验证目录结构与包名
+	expected := map[string]string{
+		"user_model.go":              "package output\n",
+		"internal/user/user_repo.go": "package user\n",
+		"internal/service/user.go":   "package service // UserService\n",
+		"user/user_handler.go":       "package user\n",
+	}
+	for name, content := range expected {
+		actual, err := os.ReadFile(filepath.Join(outputDir, filepath.FromSlash(name)))
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(actual))
+	}
+
+	// kebab-case 目录名转换为合法的包名
+	err = engine.Generate(templateDir, outputDir, "UserProfile")
+	assert.NoError(t, err)
+	actual, err := os.ReadFile(filepath.Join(outputDir, "user-profile", "user_profile_handler.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package userprofile\n", string(actual))
+}
+
+func TestGenerateNonGoFiles(t *testing.T) {
+	// 创建临时目录
+	tempDir := t.TempDir()
+
+	templateDir := filepath.Join(tempDir, "template")
+	files := map[string]string{
+		"model.tpl":         `package {{.PackageName}}`,
+		"model_test.go.tpl": `package {{.PackageName}}_test`,
+		"repo_test.tpl":     `package {{.PackageName}}`,
+		"schema.sql.tpl":    `CREATE TABLE {{.TypeSnake}} (id INT);`,
+		"fixture.yaml.tpl":  "{{/* go-gen: prefix=false */ -}}\nname: {{.TypeSnake}}",
+	}
+	for name, content := range files {
+		err := os.MkdirAll(templateDir, 0755)
+		assert.NoError(t, err)
+		err = os.WriteFile(filepath.Join(templateDir, name), []byte(content), 0644)
+		assert.NoError(t, err)
+	}
+
+	outputDir := filepath.Join(tempDir, "output")
+	err := os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	engine := NewEngine(naming.StyleSnake)
+	err = engine.Generate(templateDir, outputDir, "user")
+	assert.NoError(t, err)
+
+	expected := map[string]string{
+		"user_model.go":     "package output\n",
+		"user_test.go":      "package output_test\n",
+		"user_repo_test.go": "package output\n",
+		"user_schema.sql":   "CREATE TABLE user (id INT);",
+		"fixture.yaml":      "name: user",
+	}
+	for name, content := range expected {
+		actual, err := os.ReadFile(filepath.Join(outputDir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(actual))
+	}
+}
+
+func TestGenerateDuplicateOutput(t *testing.T) {
+	// 创建临时目录
+	tempDir := t.TempDir()
+
+	// 两个模型测试模板都生成 user_test.go
+	templateDir := filepath.Join(tempDir, "template")
+	err := os.MkdirAll(templateDir, 0755)
+	assert.NoError(t, err)
+	for _, name := range []string{"model_test.go.tpl", "model_test.tpl"} {
+		err = os.WriteFile(filepath.Join(templateDir, name), []byte(`package {{.PackageName}}_test`), 0644)
+		assert.NoError(t, err)
+	}
+
+	outputDir := filepath.Join(tempDir, "output")
+	engine := NewEngine(naming.StyleSnake)
+	err = engine.Generate(templateDir, outputDir, "user")
+	assert.ErrorContains(t, err, "templates model_test.go.tpl and model_test.tpl both generate user_test.go")
+	_, err = os.Stat(outputDir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestGenerateFormatsGoFiles(t *testing.T) {
+	// 创建临时目录
+	tempDir := t.TempDir()
+
+	templateDir := filepath.Join(tempDir, "template")
+	err := os.MkdirAll(templateDir, 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(templateDir, "model.tpl"), []byte(`package {{.PackageName}}
+import (
+	"context"
+	"fmt"
+)
+type {{.TypePascal}} struct {
+Id string   
+	CreatedTime time.Time
+}
+`), 0644)
+	assert.NoError(t, err)
+
+	outputDir := filepath.Join(tempDir, "output")
+	err = os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	engine := NewEngine(naming.StyleSnake)
+	err = engine.Generate(templateDir, outputDir, "user")
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_model.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, `package output
+
+import (
+	"time"
+)
+
+type User struct {
+	Id          string
+	CreatedTime time.Time
+}
+`, string(content))
+}
+
+func TestGenerateWithSyntaxError(t *testing.T) {
+	// 创建临时目录
+	tempDir := t.TempDir()
+
+	templateDir := filepath.Join(tempDir, "template")
+	err := os.MkdirAll(templateDir, 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(templateDir, "model.tpl"), []byte("package {{.PackageName}}\n\ntype {{.TypePascal}} struct {\n"), 0644)
+	assert.NoError(t, err)
+
+	outputDir := filepath.Join(tempDir, "output")
+	err = os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	// 错误信息包含模板名和行号
+	engine := NewEngine(naming.StyleSnake)
+	err = engine.Generate(templateDir, outputDir, "user")
+	assert.ErrorContains(t, err, "model.tpl")
+	assert.ErrorContains(t, err, "line 3")
+}
+
+func TestGenerateBuiltinMongoTemplate(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "model")
+	err := os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	engine := NewEngine(naming.StyleSnake)
+	err = engine.Generate(filepath.Join("..", "..", "template", "mongo"), outputDir, "UserProfile")
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_profile_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "type (\n\tUserProfile struct {")
+	assert.NotContains(t, string(content), "\t\n")
+}
+
+func TestGenerateBuiltinMongoTemplateWithFields(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "model")
+	err := os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	engine := NewEngine(naming.StyleSnake, WithGenerator("mongo"), WithFields([]field.Field{
+		{Name: "name", Type: "string", Comment: "Display name"},
+		{Name: "age", Type: "int", Index: true},
+		{Name: "email", Type: "string", Unique: true},
+		{Name: "tags", Type: "[]string", Optional: true},
+	}))
+	err = engine.Generate(BuiltinTemplate, outputDir, "User")
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_model.go"))
+	assert.NoError(t, err)
+	for _, expected := range []string{
+		"\t\t// Display name\n\t\tName  string   `bson:\"name\" json:\"name\"`\n",
+		"\t\tAge   int      `bson:\"age\" json:\"age\"`\n",
+		"\t\tTags  []string `bson:\"tags,omitempty\" json:\"tags,omitempty\"`\n",
+		"\t\tFindByEmail(ctx context.Context, value string) (*User, error)\n\t\tEnsureIndexes(ctx context.Context) error\n",
+		"\t\tAge   *int\n\t\tEmail *string\n",
+		"\tif c.Age != nil {\n\t\tfilter[\"age\"] = *c.Age\n\t}\n",
+		"bson.M{\"email\": value}",
+		"{Keys: bson.D{{Key: \"email\", Value: 1}}, Options: options.Index().SetUnique(true)},\n",
+		"{Keys: bson.D{{Key: \"age\", Value: 1}}},\n",
+		"\toptions \"go.mongodb.org/mongo-driver/mongo/options\"\n",
+		"\t\tId string `bson:\"_id,omitempty\" json:\"id,omitempty\"`\n",
+		"\tif user.Id == \"\" {\n\t\tuser.Id = primitive.NewObjectID().Hex()\n\t}\n",
+		"\tif c.Id != \"\" {\n",
+	} {
+		assert.Contains(t, string(content), expected)
+	}
+
+	// idType 指定 _id 的类型，零值判断适用于任意可比较类型
+	engine = NewEngine(naming.StyleSnake, WithGenerator("mongo"), WithVars(map[string]interface{}{"idType": "int64"}))
+	err = engine.Generate(BuiltinTemplate, outputDir, "Counter")
+	assert.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(outputDir, "counter_model.go"))
+	assert.NoError(t, err)
+	for _, expected := range []string{
+		"\t\tId int64 `bson:\"_id,omitempty\" json:\"id,omitempty\"`\n",
+		"\t\tFindById(ctx context.Context, id int64) (*Counter, error)\n",
+		"\tvar zeroId int64\n\tif c.Id != zeroId {\n",
+	} {
+		assert.Contains(t, string(content), expected)
+	}
+	assert.NotContains(t, string(content), "primitive")
+
+	// 没有索引字段时不生成 EnsureIndexes，也不导入 options
+	engine = NewEngine(naming.StyleSnake, WithGenerator("mongo"), WithFields([]field.Field{{Name: "name", Type: "string"}}))
+	err = engine.Generate(BuiltinTemplate, outputDir, "Group")
+	assert.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(outputDir, "group_model.go"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "EnsureIndexes")
+	assert.NotContains(t, string(content), "options")
+}
+
+func TestGenerateBuiltinSQLTemplates(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "model")
+	err := os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	fields := []field.Field{
+		{Name: "id", Type: "int64", Tag: "id", PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", Type: "string", Tag: "email", Unique: true},
+		{Name: "team_id", Type: "*int32", Tag: "team_id", Optional: true, Index: true},
+		{Name: "full_name", Type: "*string", Tag: "full_name", Optional: true, Computed: true},
+		{Name: "created_at", Type: "time.Time", Tag: "created_at"},
+	}
+
+	// MySQL 使用反引号和 ? 占位符，自增主键通过 LastInsertId 回填
+	engine := NewEngine(naming.StyleSnake, WithGenerator("mysql"), WithFields(fields), WithVars(map[string]interface{}{"table": "members"}))
+	err = engine.Generate(BuiltinTemplate, outputDir, "User")
+	assert.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_model.go"))
+	assert.NoError(t, err)
+	for _, expected := range []string{
+		"\t\tTeamId    *int32    `db:\"team_id\" json:\"teamId,omitempty\"`\n",
+		"\t\tDelete(ctx context.Context, id int64) error\n",
+		"\t\tFindByEmail(ctx context.Context, value string) (*User, error)\n",
+		"\t\tId     *int64\n\t\tIds    []int64\n\t\tEmail  *string\n\t\tTeamId *int32\n",
+		"\tnow := time.Now()\n\tuser.CreatedAt = now\n\n",
+		"\"INSERT INTO `members` (`email`, `team_id`, `created_at`) VALUES (?, ?, ?)\",\n",
+		"\tuser.Id = int64(id)\n",
+		"\"UPDATE `members` SET `email` = ?, `team_id` = ?, `created_at` = ? WHERE `id` = ?\",\n",
+		"\"SELECT `id`, `email`, `team_id`, `full_name`, `created_at` FROM `members` WHERE `email` = ? LIMIT 1\"",
+		"\t\twhere = append(where, \"`team_id` = ?\")\n",
+	} {
+		assert.Contains(t, string(content), expected)
+	}
+	assert.NotContains(t, string(content), "fmt")
+
+	// PostgreSQL 使用双引号和 $n 占位符，自增主键通过 RETURNING 回填
+	engine = NewEngine(naming.StyleSnake, WithGenerator("postgres"), WithFields(fields))
+	err = engine.Generate(BuiltinTemplate, outputDir, "Account")
+	assert.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(outputDir, "account_model.go"))
+	assert.NoError(t, err)
+	for _, expected := range []string{
+		"`INSERT INTO \"accounts\" (\"email\", \"team_id\", \"created_at\") VALUES ($1, $2, $3) RETURNING \"id\"`,\n",
+		"\t).Scan(&account.Id)\n",
+		"`UPDATE \"accounts\" SET \"email\" = $1, \"team_id\" = $2, \"created_at\" = $3 WHERE \"id\" = $4`,\n",
+		"`DELETE FROM \"accounts\" WHERE \"id\" = $1`",
+		"where = append(where, fmt.Sprintf(`\"team_id\" = $%d`, len(args)))\n",
+	} {
+		assert.Contains(t, string(content), expected)
+	}
+
+	// 有默认值的列为 nil 时不插入，由数据库赋值，PostgreSQL 通过 RETURNING 回填
+	fields = []field.Field{
+		{Name: "id", Type: "int64", Tag: "id", PrimaryKey: true, AutoIncrement: true},
+		{Name: "name", Type: "string", Tag: "name"},
+		{Name: "is_active", Type: "*bool", Tag: "is_active", Default: true},
+		{Name: "created_at", Type: "*time.Time", Tag: "created_at", Default: true},
+	}
+	engine = NewEngine(naming.StyleSnake, WithGenerator("postgres"), WithFields(fields))
+	err = engine.Generate(BuiltinTemplate, outputDir, "Team")
+	assert.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(outputDir, "team_model.go"))
+	assert.NoError(t, err)
+	for _, expected := range []string{
+		"\tnow := time.Now()\n\tteam.CreatedAt = &now\n\n",
+		"\tcolumns := []string{`\"name\"`}\n\targs := []interface{}{\n\t\tteam.Name,\n\t}\n",
+		"\tif team.IsActive != nil {\n\t\tcolumns = append(columns, `\"is_active\"`)\n\t\targs = append(args, team.IsActive)\n\t}\n",
+		"\treturn m.db.QueryRowContext(ctx, query+` RETURNING \"id\", \"is_active\", \"created_at\"`, args...).Scan(\n" +
+			"\t\t&team.Id,\n\t\t&team.IsActive,\n\t\t&team.CreatedAt,\n\t)\n",
+		// 更新时同样跳过为 nil 的默认值列，避免将 NOT NULL 列写为 NULL
+		"\tset := []string{`\"name\"`}\n\targs := []interface{}{\n\t\tteam.Name,\n\t}\n" +
+			"\tif team.IsActive != nil {\n\t\tset = append(set, `\"is_active\"`)\n\t\targs = append(args, team.IsActive)\n\t}\n",
+		"\t_, err := m.db.ExecContext(ctx, `UPDATE \"teams\" SET `+strings.Join(set, \", \")+fmt.Sprintf(` WHERE \"id\" = $%d`, len(args)), args...)\n",
+	} {
+		assert.Contains(t, string(content), expected)
+	}
+
+	engine = NewEngine(naming.StyleSnake, WithGenerator("mysql"), WithFields(fields))
+	err = engine.Generate(BuiltinTemplate, outputDir, "Group")
+	assert.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(outputDir, "group_model.go"))
+	assert.NoError(t, err)
+	for _, expected := range []string{
+		"\tcolumns := []string{\"`name`\"}\n",
+		"\t\tcolumns = append(columns, \"`is_active`\")\n",
+		"\t\tquery = \"INSERT INTO `groups` (\" + strings.Join(columns, \", \") + \") VALUES (?\" + strings.Repeat(\", ?\", len(columns)-1) + \")\"\n",
+		"\tresult, err := m.db.ExecContext(ctx, query, args...)\n",
+		"\tset := []string{\"`name` = ?\"}\n",
+		"\t\tset = append(set, \"`is_active` = ?\")\n",
+		"\t_, err := m.db.ExecContext(ctx, \"UPDATE `groups` SET \"+strings.Join(set, \", \")+\" WHERE `id` = ?\", args...)\n",
+	} {
+		assert.Contains(t, string(content), expected)
+	}
+	assert.NotContains(t, string(content), "len(set) == 0")
+
+	// 只有默认值列时，全部为 nil 则不更新
+	fields = []field.Field{
+		{Name: "id", Type: "string", Tag: "id", PrimaryKey: true},
+		{Name: "is_active", Type: "*bool", Tag: "is_active", Default: true},
+	}
+	engine = NewEngine(naming.StyleSnake, WithGenerator("mysql"), WithFields(fields))
+	err = engine.Generate(BuiltinTemplate, outputDir, "Token")
+	assert.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(outputDir, "token_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "\tset := []string{}\n\targs := []interface{}{}\n")
+	assert.Contains(t, string(content), "\tif len(set) == 0 {\n\t\treturn nil\n\t}\n")
+}
+
+func TestGenerateDryRun(t *testing.T) {
+	// 创建临时目录
+	tempDir := t.TempDir()
+
+	templateDir := filepath.Join(tempDir, "template")
+	files := map[string]string{
+		"model.tpl":       "package {{.PackageName}}\n",
+		"dao.tpl":         "package {{.PackageName}}\n\ntype {{.TypePascal}}Dao struct{}\n",
+		"sub/handler.tpl": "package {{.PackageName}}\n",
+		"schema.sql.tpl":  "CREATE TABLE {{.TypeSnake}} (id INT);\n",
+	}
+	for name, content := range files {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(templateDir, name)), 0755)
+		assert.NoError(t, err)
+		err = os.WriteFile(filepath.Join(templateDir, name), []byte(content), 0644)
+		assert.NoError(t, err)
+	}
+
+	// 已有文件：一个内容相同，一个内容不同
+	outputDir := filepath.Join(tempDir, "output")
+	err := os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(outputDir, "user_model.go"), []byte("package output\n"), 0644)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(outputDir, "user_dao.go"), []byte("package output\n"), 0644)
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	engine := NewEngine(naming.StyleSnake, WithDryRun(true), WithOutput(&out))
+	err = engine.Generate(templateDir, outputDir, "user")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "modify    user_dao.go\n"+
+		"unchanged user_model.go\n"+
+		"create    user_schema.sql\n"+
+		"create    sub/user_handler.go\n", out.String())
+
+	// 不写入任何文件
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_dao.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package output\n", string(content))
+	_, err = os.Stat(filepath.Join(outputDir, "user_schema.sql"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(outputDir, "sub"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestGenerateDiff(t *testing.T) {
+	// 创建临时目录
+	tempDir := t.TempDir()
+
+	templateDir := filepath.Join(tempDir, "template")
+	err := os.MkdirAll(templateDir, 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(templateDir, "model.tpl"), []byte("package {{.PackageName}}\n\ntype {{.TypePascal}} struct {\n\tName string\n}\n"), 0644)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(templateDir, "schema.sql.tpl"), []byte("CREATE TABLE {{.TypeSnake}};\n"), 0644)
+	assert.NoError(t, err)
+
+	outputDir := filepath.Join(tempDir, "output")
+	err = os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+	existing := "package output\n\ntype User struct {\n\tID string\n}\n"
+	err = os.WriteFile(filepath.Join(outputDir, "user_model.go"), []byte(existing), 0644)
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	engine := NewEngine(naming.StyleSnake, WithDiff(true), WithOutput(&out))
+	err = engine.Generate(templateDir, outputDir, "user")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "--- a/user_model.go\n"+
+		"+++ b/user_model.go\n"+
+		"@@ -1,5 +1,5 @@\n"+
+		" package output\n"+
+		" \n"+
+		" type User struct {\n"+
+		"-\tID string\n"+
+		"+\tName string\n"+
+		" }\n"+
+		"--- /dev/null\n"+
+		"+++ b/user_schema.sql\n"+
+		"@@ -0,0 +1 @@\n"+
+		"+CREATE TABLE user;\n", out.String())
+
+	// 不写入任何文件
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_model.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, existing, string(content))
+	_, err = os.Stat(filepath.Join(outputDir, "user_schema.sql"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestGenerateWithConflictPolicy(t *testing.T) {
+	const existing = "package output\n\n// hand-written\n"
+	const generated = "package output\n"
+
+	testCases := []struct {
+		name          string
+		template      string
+		policy        ConflictPolicy
+		input         string
+		expected      string
+		expectBackup  bool
+		expectError   bool
+		expectMessage string
+	}{
+		{name: "overwrite by default", template: "package {{.PackageName}}\n", expected: generated},
+		{name: "skip", template: "package {{.PackageName}}\n", policy: ConflictSkip, expected: existing, expectMessage: "skip      user_model.go\n"},
+		{name: "backup", template: "package {{.PackageName}}\n", policy: ConflictBackup, expected: generated, expectBackup: true},
+		{name: "fail", template: "package {{.PackageName}}\n", policy: ConflictFail, expected: existing, expectError: true},
+		{name: "prompt accepted", template: "package {{.PackageName}}\n", policy: ConflictPrompt, input: "y\n", expected: generated},
+		{name: "prompt declined", template: "package {{.PackageName}}\n", policy: ConflictPrompt, input: "n\n", expected: existing},
+		{name: "template default", template: "{{/* go-gen: on-conflict=skip */ -}}\npackage {{.PackageName}}\n", expected: existing},
+		{name: "flag overrides template", template: "{{/* go-gen: on-conflict=skip */ -}}\npackage {{.PackageName}}\n", policy: ConflictOverwrite, expected: generated},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// 创建临时目录
+			tempDir := t.TempDir()
+
+			templateDir := filepath.Join(tempDir, "template")
+			err := os.MkdirAll(templateDir, 0755)
+			assert.NoError(t, err)
+			err = os.WriteFile(filepath.Join(templateDir, "model.tpl"), []byte(tc.template), 0644)
+			assert.NoError(t, err)
+			err = os.WriteFile(filepath.Join(templateDir, "dao.tpl"), []byte("package {{.PackageName}}\n"), 0644)
+			assert.NoError(t, err)
+
+			outputDir := filepath.Join(tempDir, "output")
+			err = os.MkdirAll(outputDir, 0755)
+			assert.NoError(t, err)
+			outputPath := filepath.Join(outputDir, "user_model.go")
+			err = os.WriteFile(outputPath, []byte(existing), 0644)
+			assert.NoError(t, err)
+
+			var out bytes.Buffer
+			engine := NewEngine(naming.StyleSnake,
+				WithConflictPolicy(tc.policy),
+				WithInput(strings.NewReader(tc.input)),
+				WithOutput(&out),
+			)
+			err = engine.Generate(templateDir, outputDir, "user")
+			if tc.expectError {
+				assert.Error(t, err)
+				// 冲突时不写入任何文件
+				_, statErr := os.Stat(filepath.Join(outputDir, "user_dao.go"))
+				assert.True(t, os.IsNotExist(statErr))
+			} else {
+				assert.NoError(t, err)
+				_, statErr := os.Stat(filepath.Join(outputDir, "user_dao.go"))
+				assert.NoError(t, statErr)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(content))
+
+			backup, err := os.ReadFile(outputPath + ".bak")
+			if tc.expectBackup {
+				assert.NoError(t, err)
+				assert.Equal(t, existing, string(backup))
+			} else {
+				assert.True(t, os.IsNotExist(err))
+			}
+
+			if tc.expectMessage != "" {
+				assert.Equal(t, tc.expectMessage, out.String())
+			}
+		})
+	}
+}
+
+func TestGenerateDryRunWithConflictPolicy(t *testing.T) {
+	// 创建临时目录
+	tempDir := t.TempDir()
+
+	templateDir := filepath.Join(tempDir, "template")
+	err := os.MkdirAll(templateDir, 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(templateDir, "model.tpl"), []byte("{{/* go-gen: on-conflict=skip */ -}}\npackage {{.PackageName}}\n"), 0644)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(templateDir, "dao.tpl"), []byte("{{/* go-gen: on-conflict=fail */ -}}\npackage {{.PackageName}}\n"), 0644)
+	assert.NoError(t, err)
+
+	outputDir := filepath.Join(tempDir, "output")
+	err = os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+	for _, name := range []string{"user_model.go", "user_dao.go"} {
+		err = os.WriteFile(filepath.Join(outputDir, name), []byte("package x\n"), 0644)
+		assert.NoError(t, err)
+	}
+
+	var out bytes.Buffer
+	engine := NewEngine(naming.StyleSnake, WithDryRun(true), WithOutput(&out))
+	err = engine.Generate(templateDir, outputDir, "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "conflict  user_dao.go\nskip      user_model.go\n", out.String())
+}
+
+func TestGenerateKeepsProtectedRegions(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "model")
+	err := os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	templateDir := filepath.Join("..", "..", "template", "mongo")
+	engine := NewEngine(naming.StyleSnake)
+	err = engine.Generate(templateDir, outputDir, "User")
+	assert.NoError(t, err)
+
+	// 在受保护区域中添加字段和方法
+	outputPath := filepath.Join(outputDir, "user_model.go")
+	content, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	edited := strings.Replace(string(content), "\t\t// Add your fields here, they are kept when the model is regenerated\n",
+		"\t\tName string `bson:\"name\" json:\"name\"`\n\t\tRaw json.RawMessage `bson:\"raw\" json:\"raw\"`\n", 1)
+	edited = strings.Replace(edited, "// Add your methods here, they are kept when the model is regenerated\n",
+		"func (u *User) Title() string { return u.Name }\n", 1)
+	assert.NotEqual(t, string(content), edited)
+	err = os.WriteFile(outputPath, []byte(edited), 0644)
+	assert.NoError(t, err)
+
+	// 重新生成后保留手动添加的内容，并补全新用到的 import
+	err = engine.Generate(templateDir, outputDir, "User")
+	assert.NoError(t, err)
+
+	content, err = os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "\t\tName string          `bson:\"name\" json:\"name\"`\n")
+	assert.Contains(t, string(content), "\t\tRaw  json.RawMessage `bson:\"raw\" json:\"raw\"`\n")
+	assert.Contains(t, string(content), "func (u *User) Title() string { return u.Name }\n")
+	assert.Contains(t, string(content), "\t\"encoding/json\"\n")
+	assert.NotContains(t, string(content), "Add your fields here")
+
+	// 再次生成时内容保持不变
+	var out bytes.Buffer
+	engine = NewEngine(naming.StyleSnake, WithDryRun(true), WithOutput(&out))
+	err = engine.Generate(templateDir, outputDir, "User")
+	assert.NoError(t, err)
+	assert.Equal(t, "unchanged user_model.go\n", out.String())
+}
+
+func TestGenerateWithBuiltinTemplate(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "model")
+	err := os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	// 内置模板不需要执行任何外部命令，调用命令执行器会导致 panic
+	oldCommander := defaultCommander
+	defer func() { defaultCommander = oldCommander }()
+	defaultCommander = nil
+
+	engine := NewEngine(naming.StyleSnake, WithGenerator("mongo"))
+	err = engine.Generate(BuiltinTemplate, outputDir, "User")
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "package model\n")
+	assert.Contains(t, string(content), "func NewUserModel(db *mongo.Database) UserModel {")
+	assert.Contains(t, string(content), `model: db.Collection("user"),`)
+
+	// collection 变量指定集合名称
+	engine = NewEngine(naming.StyleSnake, WithGenerator("mongo"), WithVars(map[string]interface{}{"collection": "accounts"}))
+	err = engine.Generate(BuiltinTemplate, outputDir, "User")
+	assert.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(outputDir, "user_model.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `model: db.Collection("accounts"),`)
+}
+
+func TestGenerateFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"go-gen.yaml":     {Data: []byte("name: test\ngenerators:\n  - name: mongo\n    path: mongo\n")},
+		"mongo/model.tpl": {Data: []byte(`package {{.PackageName}}{{template "header" .}}`)},
+		"_partials/header.partial.tpl": {Data: []byte(`{{define "header"}}
+
+type {{.TypePascal}} struct{}{{end}}`)},
+	}
+
+	outputDir := filepath.Join(t.TempDir(), "model")
+	err := os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	engine := NewEngine(naming.StyleSnake, WithGenerator("mongo"))
+	err = engine.GenerateFS(fsys, outputDir, "user")
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_model.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package model\n\ntype User struct{}\n", string(content))
+}
+
+func TestGenerateWithPackageAndModule(t *testing.T) {
+	fsys := fstest.MapFS{
+		"model.tpl":           {Data: []byte("package {{.PackageName}}\n\nimport _ \"{{.Module}}/internal/db\"\n")},
+		"mock/model_mock.tpl": {Data: []byte("package {{.PackageName}}\n")},
+	}
+
+	outputDir := filepath.Join(t.TempDir(), "model")
+	err := os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	engine := NewEngine(naming.StyleSnake, WithPackage("entity"), WithModule("github.com/org/app"))
+	err = engine.GenerateFS(fsys, outputDir, "user")
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_model.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package entity\n\nimport _ \"github.com/org/app/internal/db\"\n", string(content))
+
+	// 子目录中的文件仍使用所在目录名作为包名
+	content, err = os.ReadFile(filepath.Join(outputDir, "mock", "user_model_mock.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package mock\n", string(content))
+}
+
+func TestParseGitSource(t *testing.T) {
+	testCases := []struct {
+		name           string
+		source         string
+		expectedURL    string
+		expectedRef    string
+		expectedSubdir string
+	}{
+		{"plain", "https://github.com/user/repo.git", "https://github.com/user/repo.git", "", ""},
+		{"subdir", "https://github.com/user/repo.git//template/mongo", "https://github.com/user/repo.git", "", "template/mongo"},
+		{"subdir and ref", "https://github.com/user/repo.git//template@v1.4.0", "https://github.com/user/repo.git", "v1.4.0", "template"},
+		{"ref before subdir", "https://github.com/user/repo.git@v1.4.0//template", "https://github.com/user/repo.git", "v1.4.0", "template"},
+		{"ssh subdir", "git@github.com:user/repo.git//template/", "git@github.com:user/repo.git", "", "template"},
+		{"ssh subdir and ref", "git@github.com:user/repo.git//template@main", "git@github.com:user/repo.git", "main", "template"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url, ref, subdir := parseGitSource(tc.source)
+			assert.Equal(t, tc.expectedURL, url)
+			assert.Equal(t, tc.expectedRef, ref)
+			assert.Equal(t, tc.expectedSubdir, subdir)
+		})
+	}
+}
+
+func TestGenerateWithPath(t *testing.T) {
+	// 创建包含多个模板包的目录，根目录下的模板不应被渲染
+	templateDir := t.TempDir()
+	files := map[string]string{
+		"broken.tpl":              "{{.Missing",
+		"packs/a/mongo/model.tpl": "package {{.PackageName}}\n\ntype {{.TypePascal}} struct{}\n",
+		"packs/a/mysql/model.tpl": "{{.Missing",
+		"packs/b/model.tpl":       "{{.Missing",
+	}
+	for name, content := range files {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(templateDir, name)), 0755)
+		assert.NoError(t, err)
+		err = os.WriteFile(filepath.Join(templateDir, name), []byte(content), 0644)
+		assert.NoError(t, err)
+	}
+
+	outputDir := filepath.Join(t.TempDir(), "model")
+	err := os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	// 使用子目录中的模板包，并默认使用与生成器同名的目录
+	engine := NewEngine(naming.StyleSnake, WithGenerator("mongo"), WithPath("packs/a"))
+	err = engine.Generate(templateDir, outputDir, "user")
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_model.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package model\n\ntype User struct{}\n", string(content))
+
+	// 子目录不存在或不在模板目录内
+	for _, dir := range []string{"packs/c", "../packs", "packs/a/mongo/model.tpl"} {
+		engine = NewEngine(naming.StyleSnake, WithPath(dir))
+		err = engine.Generate(templateDir, outputDir, "user")
+		assert.Error(t, err, dir)
+	}
+}
+
+func TestGenerateWithGitSubdir(t *testing.T) {
+	// 保存原始的命令执行器
+	oldCommander := defaultCommander
+	defer func() { defaultCommander = oldCommander }()
+	defaultCommander = &MockCommander{}
+
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("GO_GEN_CACHE", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+
+	// 模拟已缓存的仓库，仓库根目录下的模板不应被渲染
+	cacheDir := filepath.Join(homeDir, ".go-gen", cacheKey("https://github.com/user/repo.git", ""))
+	files := map[string]string{
+		".git/HEAD":                "ref: refs/heads/main",
+		"docs/example.tpl":         "{{.Missing",
+		"template/mongo/model.tpl": "package {{.PackageName}}\n\ntype {{.TypePascal}} struct{}\n",
+	}
+	for name, content := range files {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(cacheDir, name)), 0755)
+		assert.NoError(t, err)
+		err = os.WriteFile(filepath.Join(cacheDir, name), []byte(content), 0644)
+		assert.NoError(t, err)
+	}
+
+	outputDir := filepath.Join(t.TempDir(), "model")
+	err := os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	engine := NewEngine(naming.StyleSnake, WithGenerator("mongo"))
+	err = engine.Generate("https://github.com/user/repo.git//template", outputDir, "user")
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_model.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package model\n\ntype User struct{}\n", string(content))
+
+	// 同时通过 //subdir 和 WithPath 指定不同的目录
+	engine = NewEngine(naming.StyleSnake, WithPath("other"))
+	err = engine.Generate("https://github.com/user/repo.git//template", outputDir, "user")
+	assert.ErrorContains(t, err, "template path specified twice")
+}
+
+func TestGenerateWithCacheFallback(t *testing.T) {
+	// 保存原始的命令执行器
+	oldCommander := defaultCommander
+	defer func() { defaultCommander = oldCommander }()
+
+	cacheRoot := t.TempDir()
+	t.Setenv("GO_GEN_CACHE", cacheRoot)
+
+	// 模拟已缓存的仓库
+	const repoURL = "https://unreachable.example.com/user/repo"
+	cacheDir := filepath.Join(cacheRoot, cacheKey(repoURL, ""))
+	err := os.MkdirAll(cacheDir, 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(cacheDir, "model.tpl"), []byte("package {{.PackageName}}\n"), 0644)
+	assert.NoError(t, err)
+
+	outputDir := filepath.Join(t.TempDir(), "model")
+	err = os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	// 无法访问远程仓库时使用缓存并给出警告
+	defaultCommander = &MockCommander{}
+	var out bytes.Buffer
+	engine := NewEngine(naming.StyleSnake, WithOutput(&out))
+	err = engine.Generate(repoURL, outputDir, "user")
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "warning: get repo hash:")
+	assert.Contains(t, out.String(), "using the cached copy of "+repoURL)
+	_, err = os.Stat(filepath.Join(outputDir, "user_model.go"))
+	assert.NoError(t, err)
+
+	// 离线模式不执行任何 git 命令
+	commander := &MockCommander{}
+	defaultCommander = commander
+	out.Reset()
+	engine = NewEngine(naming.StyleSnake, WithOffline(true), WithOutput(&out))
+	err = engine.Generate(repoURL, outputDir, "user")
+	assert.NoError(t, err)
+	assert.Empty(t, commander.calls)
+	assert.Empty(t, out.String())
+
+	// 没有缓存时无法回退
+	engine = NewEngine(naming.StyleSnake, WithOutput(&out))
+	err = engine.Generate("https://unreachable.example.com/user/other", outputDir, "user")
+	assert.ErrorContains(t, err, "get repo hash")
+
+	engine = NewEngine(naming.StyleSnake, WithOffline(true))
+	err = engine.Generate("https://github.com/user/other", outputDir, "user")
+	assert.ErrorContains(t, err, "cannot be fetched offline")
+}
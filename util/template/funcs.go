@@ -0,0 +1,175 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/lewinz/go-gen/util/naming"
+)
+
+// FuncMap 返回所有模板默认可用的函数集合
+//
+// 参数顺序与 sprig 保持一致，被处理的值总是最后一个参数，
+// 以便在管道中使用，例如 {{ .Type | snake | plural }}
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		// 命名风格
+		"snake":  convertFunc(naming.StyleSnake),
+		"camel":  convertFunc(naming.StyleCamel),
+		"pascal": convertFunc(naming.StylePascal),
+		"kebab":  convertFunc(naming.StyleKebab),
+
+		// 单复数
+		"plural":   naming.Pluralize,
+		"singular": naming.Singularize,
+
+		// 字符串处理
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"title":      title,
+		"untitle":    untitle,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"repeat":     func(count int, s string) string { return strings.Repeat(s, count) },
+		"join":       join,
+		"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+		"indent":     indent,
+		"nindent":    func(spaces int, s string) string { return "\n" + indent(spaces, s) },
+		"quote":      quote,
+		"squote":     func(v interface{}) string { return "'" + toString(v) + "'" },
+
+		// 值处理
+		"default": defaultValue,
+		"empty":   isEmpty,
+		"dict":    dict,
+		"list":    func(items ...interface{}) []interface{} { return items },
+
+		// 算术
+		"add": func(a, b int) int { return a + b },
+		"sub": func(a, b int) int { return a - b },
+
+		// 时间
+		"now":  time.Now,
+		"date": func(layout string, t time.Time) string { return t.Format(layout) },
+	}
+}
+
+// convertFunc 返回按指定风格转换命名的模板函数
+func convertFunc(style naming.Style) func(string) string {
+	converter := naming.NewConverter(style)
+	return converter.Convert
+}
+
+// title 将首字母转为大写
+func title(s string) string {
+	if s == "" {
+		return ""
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// untitle 将首字母转为小写
+func untitle(s string) string {
+	if s == "" {
+		return ""
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+// join 使用分隔符连接任意切片
+func join(sep string, v interface{}) string {
+	return strings.Join(toStrings(v), sep)
+}
+
+// indent 为每一行添加指定数量的空格
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// quote 为值加上 Go 风格的双引号
+func quote(v interface{}) string {
+	return strconv.Quote(toString(v))
+}
+
+// defaultValue 当 v 为空值时返回 def
+func defaultValue(def, v interface{}) interface{} {
+	if isEmpty(v) {
+		return def
+	}
+	return v
+}
+
+// isEmpty 判断值是否为空（nil、零值、空集合）
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return rv.IsZero()
+	}
+}
+
+// dict 使用键值对构建 map，便于向子模板传递多个参数
+func dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("dict requires an even number of arguments")
+	}
+	m := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict key %v is not a string", pairs[i])
+		}
+		m[key] = pairs[i+1]
+	}
+	return m, nil
+}
+
+// toString 将任意值转换为字符串
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case fmt.Stringer:
+		return s.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// toStrings 将切片或数组转换为字符串切片，其他值视为单元素切片
+func toStrings(v interface{}) []string {
+	if v == nil {
+		return nil
+	}
+	if s, ok := v.([]string); ok {
+		return s
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []string{toString(v)}
+	}
+	result := make([]string, rv.Len())
+	for i := range result {
+		result[i] = toString(rv.Index(i).Interface())
+	}
+	return result
+}
@@ -0,0 +1,85 @@
+package template
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// render 使用默认函数集合渲染模板字符串
+func render(t *testing.T, text string, data interface{}) string {
+	t.Helper()
+	tmpl, err := template.New("test").Funcs(FuncMap()).Parse(text)
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	assert.NoError(t, tmpl.Execute(&buf, data))
+	return buf.String()
+}
+
+func TestFuncMap(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		data     interface{}
+		expected string
+	}{
+		{"snake", `{{snake "UserProfile"}}`, nil, "user_profile"},
+		{"camel", `{{camel "user_profile"}}`, nil, "userProfile"},
+		{"pascal", `{{pascal "user-profile"}}`, nil, "UserProfile"},
+		{"kebab", `{{kebab "UserProfile"}}`, nil, "user-profile"},
+		{"plural pipeline", `{{"UserProfile" | snake | plural}}`, nil, "user_profiles"},
+		{"singular", `{{singular "categories"}}`, nil, "category"},
+		{"upper", `{{upper "user"}}`, nil, "USER"},
+		{"lower", `{{lower "USER"}}`, nil, "user"},
+		{"title", `{{title "user"}}`, nil, "User"},
+		{"untitle", `{{untitle "User"}}`, nil, "user"},
+		{"trim", `{{trim "  user  "}}`, nil, "user"},
+		{"replace", `{{"a-b-c" | replace "-" "_"}}`, nil, "a_b_c"},
+		{"contains", `{{if contains "ser" "user"}}yes{{end}}`, nil, "yes"},
+		{"join strings", `{{join ", " .}}`, []string{"a", "b"}, "a, b"},
+		{"join list", `{{list 1 2 3 | join "-"}}`, nil, "1-2-3"},
+		{"split", `{{range split "," "a,b"}}[{{.}}]{{end}}`, nil, "[a][b]"},
+		{"indent", `{{indent 2 "a\nb"}}`, nil, "  a\n  b"},
+		{"nindent", `{{nindent 2 "a"}}`, nil, "\n  a"},
+		{"quote", `{{quote "a\"b"}}`, nil, `"a\"b"`},
+		{"squote", `{{squote "a"}}`, nil, `'a'`},
+		{"default empty", `{{default "x" ""}}`, nil, "x"},
+		{"default set", `{{default "x" "y"}}`, nil, "y"},
+		{"default nil", `{{default 10 .}}`, nil, "10"},
+		{"dict", `{{$d := dict "a" 1 "b" "two"}}{{$d.a}}-{{$d.b}}`, nil, "1-two"},
+		{"date", `{{date "2006" now | len}}`, nil, "4"},
+		{"add", `{{add 1 2}}`, nil, "3"},
+		{"add pipeline", `{{2 | add 1}}`, nil, "3"},
+		{"sub", `{{sub 5 2}}`, nil, "3"},
+		{"counter", `{{$n := 0}}{{range .}}{{$n = add $n 1}}{{end}}{{$n}}`, []string{"a", "b"}, "2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, render(t, tc.text, tc.data))
+		})
+	}
+}
+
+func TestDictInvalidArgs(t *testing.T) {
+	_, err := dict("a")
+	assert.Error(t, err)
+
+	_, err = dict(1, "a")
+	assert.Error(t, err)
+}
+
+func TestIsEmpty(t *testing.T) {
+	assert.True(t, isEmpty(nil))
+	assert.True(t, isEmpty(""))
+	assert.True(t, isEmpty(0))
+	assert.True(t, isEmpty(false))
+	assert.True(t, isEmpty([]string{}))
+	assert.True(t, isEmpty(map[string]int{}))
+	assert.False(t, isEmpty("a"))
+	assert.False(t, isEmpty(1))
+	assert.False(t, isEmpty(true))
+	assert.False(t, isEmpty([]int{1}))
+}
@@ -0,0 +1,83 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+var (
+	lockTimeout       = 2 * time.Minute        // How long to wait for a lock held by another process
+	lockRetryInterval = 100 * time.Millisecond // How often to retry acquiring a lock
+)
+
+// errLocked is returned by tryLock when another process holds the lock
+var errLocked = errors.New("locked")
+
+// fileLock is a lock between processes, held as an advisory lock on a lock file.
+// The operating system releases it when the process exits, so a killed process
+// never leaves a lock behind. The lock file itself is kept for the next process
+type fileLock struct {
+	file *os.File
+}
+
+// lockFile acquires an exclusive lock on the lock file at path, waiting while
+// another process holds it
+func lockFile(path string) (*fileLock, error) {
+	return acquireLock(path, true)
+}
+
+// rlockFile acquires a shared lock on the lock file at path, waiting while
+// another process holds an exclusive lock
+func rlockFile(path string) (*fileLock, error) {
+	return acquireLock(path, false)
+}
+
+// acquireLock opens the lock file at path and locks it
+func acquireLock(path string, exclusive bool) (*fileLock, error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("open lock %s: %w", path, err)
+		}
+		err = tryLock(file, exclusive)
+		if err == nil {
+			// The lock file may have been removed by the previous holder, the lock
+			// is only valid if path still refers to the locked file
+			if info, statErr := os.Stat(path); statErr == nil {
+				if locked, statErr := file.Stat(); statErr == nil && os.SameFile(info, locked) {
+					return &fileLock{file: file}, nil
+				}
+			}
+			file.Close()
+			continue
+		}
+		file.Close()
+		if !errors.Is(err, errLocked) {
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock %s held by another go-gen process", path)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// unlock releases the lock
+func (l *fileLock) unlock() error {
+	// Closing the file releases the lock
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("unlock %s: %w", l.file.Name(), err)
+	}
+	return nil
+}
+
+// remove deletes the lock file and releases the lock. Processes waiting for the
+// lock notice that the file was removed and lock a new one. The file is kept
+// where open files cannot be removed, e.g. on Windows
+func (l *fileLock) remove() error {
+	os.Remove(l.file.Name())
+	return l.unlock()
+}
@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package template
+
+import "os"
+
+// tryLock always succeeds, the platform has no advisory file locks
+func tryLock(file *os.File, exclusive bool) error {
+	return nil
+}
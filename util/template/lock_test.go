@@ -0,0 +1,227 @@
+package template
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockFile(t *testing.T) {
+	oldTimeout := lockTimeout
+	defer func() { lockTimeout = oldTimeout }()
+	lockTimeout = 200 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "entry.lock")
+	lock, err := lockFile(path)
+	assert.NoError(t, err)
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+
+	// 锁被占用时等待超时
+	_, err = lockFile(path)
+	assert.ErrorContains(t, err, "timed out waiting for lock")
+	_, err = rlockFile(path)
+	assert.ErrorContains(t, err, "timed out waiting for lock")
+
+	// 释放后锁文件保留，可以再次获取
+	err = lock.unlock()
+	assert.NoError(t, err)
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+	lock, err = lockFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, lock.unlock())
+}
+
+func TestLockFileShared(t *testing.T) {
+	oldTimeout := lockTimeout
+	defer func() { lockTimeout = oldTimeout }()
+	lockTimeout = 200 * time.Millisecond
+
+	// 共享锁可以同时持有，但会阻止独占锁
+	path := filepath.Join(t.TempDir(), "entry.lock")
+	first, err := rlockFile(path)
+	assert.NoError(t, err)
+	second, err := rlockFile(path)
+	assert.NoError(t, err)
+	_, err = lockFile(path)
+	assert.ErrorContains(t, err, "timed out waiting for lock")
+
+	assert.NoError(t, first.unlock())
+	assert.NoError(t, second.unlock())
+	lock, err := lockFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, lock.unlock())
+}
+
+func TestLockFileWaits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entry.lock")
+	lock, err := lockFile(path)
+	assert.NoError(t, err)
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		lock.unlock()
+	}()
+
+	start := time.Now()
+	second, err := lockFile(path)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+	assert.NoError(t, second.unlock())
+}
+
+func TestLockFileAbandoned(t *testing.T) {
+	oldTimeout := lockTimeout
+	defer func() { lockTimeout = oldTimeout }()
+	lockTimeout = 200 * time.Millisecond
+
+	// 进程退出时系统释放它持有的锁，留下的锁文件不会阻塞其他进程
+	path := filepath.Join(t.TempDir(), "entry.lock")
+	cmd := exec.Command(os.Args[0], "-test.run=TestLockHelperProcess", "--", path)
+	cmd.Env = append(os.Environ(), "GO_WANT_LOCK_HELPER=1")
+	output, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(output))
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+
+	lock, err := lockFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, lock.unlock())
+}
+
+// TestLockHelperProcess 获取锁后不释放就退出，模拟被终止的进程
+func TestLockHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_LOCK_HELPER") != "1" {
+		return
+	}
+	if _, err := lockFile(os.Args[len(os.Args)-1]); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestLockFileRemoved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entry.lock")
+	lock, err := lockFile(path)
+	assert.NoError(t, err)
+
+	// 等待中的进程在锁文件被删除后获取新的锁文件
+	done := make(chan *fileLock)
+	go func() {
+		second, err := lockFile(path)
+		assert.NoError(t, err)
+		done <- second
+	}()
+	time.Sleep(200 * time.Millisecond)
+	assert.NoError(t, lock.remove())
+
+	second := <-done
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+	assert.NoError(t, second.unlock())
+}
+
+func TestGetCachedTemplateConcurrent(t *testing.T) {
+	// 保存原始的命令执行器
+	oldCommander := defaultCommander
+	defer func() { defaultCommander = oldCommander }()
+	commander := &MockCommander{}
+	defaultCommander = commander
+
+	root := t.TempDir()
+	t.Setenv("GO_GEN_CACHE", root)
+
+	const repoURL = "https://github.com/user/repo"
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var lock *fileLock
+			if _, lock, errs[i] = getCachedTemplate(repoURL, "v1.4.0", nil, false); errs[i] == nil {
+				lock.unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+
+	// 只克隆一次，并且没有留下临时文件
+	clones := 0
+	for _, call := range commander.calls {
+		if call[1] == "init" {
+			clones++
+		}
+	}
+	assert.Equal(t, 1, clones)
+
+	files, err := os.ReadDir(root)
+	assert.NoError(t, err)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	key := cacheKey(repoURL, "v1.4.0")
+	assert.ElementsMatch(t, []string{key, key + ".json", key + ".lock"}, names)
+}
+
+func TestGetCachedTemplateHoldsLock(t *testing.T) {
+	oldCommander := defaultCommander
+	defer func() { defaultCommander = oldCommander }()
+	defaultCommander = &MockCommander{}
+	oldTimeout := lockTimeout
+	defer func() { lockTimeout = oldTimeout }()
+	lockTimeout = 200 * time.Millisecond
+
+	root := t.TempDir()
+	t.Setenv("GO_GEN_CACHE", root)
+
+	// 读取缓存期间持有共享锁，其他读取者不受影响，更新和删除需要等待
+	const repoURL = "https://github.com/user/repo"
+	_, lock, err := getCachedTemplate(repoURL, "v1.4.0", nil, false)
+	assert.NoError(t, err)
+	_, second, err := getCachedTemplate(repoURL, "v1.4.0", nil, true)
+	assert.NoError(t, err)
+	assert.NoError(t, second.unlock())
+	_, err = CleanCache()
+	assert.ErrorContains(t, err, "timed out waiting for lock")
+
+	assert.NoError(t, lock.unlock())
+	removed, err := CleanCache()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{cacheKey(repoURL, "v1.4.0")}, removed)
+}
+
+func TestReplaceDir(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dst := filepath.Join(root, "dst")
+	err := os.MkdirAll(src, 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(src, "new.tpl"), []byte("new"), 0644)
+	assert.NoError(t, err)
+	err = os.MkdirAll(dst, 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(dst, "old.tpl"), []byte("old"), 0644)
+	assert.NoError(t, err)
+
+	err = replaceDir(src, dst)
+	assert.NoError(t, err)
+
+	files, err := os.ReadDir(root)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	_, err = os.Stat(filepath.Join(dst, "new.tpl"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dst, "old.tpl"))
+	assert.True(t, os.IsNotExist(err))
+}
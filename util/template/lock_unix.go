@@ -0,0 +1,28 @@
+//go:build unix
+
+package template
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLock locks file with flock without waiting, it returns errLocked if
+// another process holds the lock
+func tryLock(file *os.File, exclusive bool) error {
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	for {
+		err := syscall.Flock(int(file.Fd()), how|syscall.LOCK_NB)
+		switch {
+		case errors.Is(err, syscall.EINTR):
+			continue
+		case errors.Is(err, syscall.EWOULDBLOCK):
+			return errLocked
+		}
+		return err
+	}
+}
@@ -0,0 +1,262 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ManifestName 模板包清单文件名
+	ManifestName = "go-gen.yaml"
+)
+
+// Version 当前 go-gen 的版本号，由 main 包在启动时设置，用于检查模板包的最低版本要求
+var Version = "dev"
+
+// Manifest 模板包清单，位于模板包根目录的 go-gen.yaml
+type Manifest struct {
+	Name        string              `yaml:"name"`        // 模板包名称
+	Version     string              `yaml:"version"`     // 模板包版本
+	Description string              `yaml:"description"` // 模板包描述
+	MinVersion  string              `yaml:"minVersion"`  // 要求的最低 go-gen 版本
+	Generators  []ManifestGenerator `yaml:"generators"`  // 提供的生成器
+	Variables   []ManifestVariable  `yaml:"variables"`   // 声明的变量
+	Files       []ManifestFile      `yaml:"files"`       // 模板文件与输出路径
+}
+
+// ManifestGenerator 模板包提供的生成器
+type ManifestGenerator struct {
+	Name        string `yaml:"name"`        // 生成器名称，例如 mongo
+	Description string `yaml:"description"` // 生成器描述
+	Path        string `yaml:"path"`        // 生成器模板所在的子目录
+}
+
+// ManifestVariable 模板包声明的变量
+type ManifestVariable struct {
+	Name        string      `yaml:"name"`        // 变量名
+	Type        string      `yaml:"type"`        // 变量类型：string|bool|int|float|list|map
+	Description string      `yaml:"description"` // 变量描述
+	Required    bool        `yaml:"required"`    // 是否必填
+	Default     interface{} `yaml:"default"`     // 默认值
+}
+
+// ManifestFile 模板文件与输出路径的对应关系
+type ManifestFile struct {
+	Template string `yaml:"template"` // 相对于模板包根目录的模板路径
+	Output   string `yaml:"output"`   // 相对于输出目录的输出路径，支持模板语法
+}
+
+// LoadManifest 读取模板包根目录下的清单文件，不存在时返回 nil
+func LoadManifest(templateDir string) (*Manifest, error) {
+	return LoadManifestFS(os.DirFS(templateDir))
+}
+
+// LoadManifestFS 读取文件系统 fsys 根目录下的清单文件，不存在时返回 nil
+func LoadManifestFS(fsys fs.FS) (*Manifest, error) {
+	content, err := fs.ReadFile(fsys, ManifestName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("parse manifest %s: %w", ManifestName, err)
+	}
+	if err := manifest.validate(); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", ManifestName, err)
+	}
+	return &manifest, nil
+}
+
+// validate 检查清单自身是否合法
+func (m *Manifest) validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	for _, g := range m.Generators {
+		if g.Name == "" {
+			return fmt.Errorf("generator name is required")
+		}
+	}
+	for _, v := range m.Variables {
+		if v.Name == "" {
+			return fmt.Errorf("variable name is required")
+		}
+		if !isValidVarType(v.Type) {
+			return fmt.Errorf("variable %s: invalid type %q", v.Name, v.Type)
+		}
+		if v.Default != nil {
+			if err := checkVarType(v.Type, v.Default); err != nil {
+				return fmt.Errorf("variable %s: default: %w", v.Name, err)
+			}
+		}
+	}
+	for _, f := range m.Files {
+		if f.Template == "" || f.Output == "" {
+			return fmt.Errorf("file entries require both template and output")
+		}
+	}
+	return nil
+}
+
+// CheckVersion 检查当前 go-gen 版本是否满足清单的最低版本要求
+//
+// 开发版本（无法解析为语义化版本）总是视为满足要求
+func (m *Manifest) CheckVersion(current string) error {
+	if m.MinVersion == "" {
+		return nil
+	}
+	cur, ok := parseVersion(current)
+	if !ok {
+		return nil
+	}
+	min, ok := parseVersion(m.MinVersion)
+	if !ok {
+		return fmt.Errorf("invalid minVersion %q", m.MinVersion)
+	}
+	if compareVersions(cur, min) < 0 {
+		return fmt.Errorf("template pack %s requires go-gen >= %s, current version is %s", m.Name, m.MinVersion, current)
+	}
+	return nil
+}
+
+// Generator 查找清单中声明的生成器
+//
+// 清单未声明任何生成器时，任意生成器名称都被接受并返回 nil
+func (m *Manifest) Generator(name string) (*ManifestGenerator, error) {
+	if len(m.Generators) == 0 || name == "" {
+		return nil, nil
+	}
+	for i := range m.Generators {
+		if m.Generators[i].Name == name {
+			return &m.Generators[i], nil
+		}
+	}
+	return nil, fmt.Errorf("template pack %s does not provide generator %q", m.Name, name)
+}
+
+// ResolveVars 按清单声明校验输入变量并填充默认值
+func (m *Manifest) ResolveVars(vars map[string]interface{}) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(vars))
+	for k, v := range vars {
+		resolved[k] = v
+	}
+
+	for _, v := range m.Variables {
+		value, ok := resolved[v.Name]
+		if !ok || value == nil {
+			if v.Default != nil {
+				resolved[v.Name] = v.Default
+				continue
+			}
+			if v.Required {
+				return nil, fmt.Errorf("missing required variable %q", v.Name)
+			}
+			continue
+		}
+		if err := checkVarType(v.Type, value); err != nil {
+			return nil, fmt.Errorf("variable %q: %w", v.Name, err)
+		}
+	}
+	return resolved, nil
+}
+
+// File 查找模板文件对应的清单条目
+func (m *Manifest) File(relPath string) *ManifestFile {
+	relPath = filepath.ToSlash(relPath)
+	for i := range m.Files {
+		if filepath.ToSlash(m.Files[i].Template) == relPath {
+			return &m.Files[i]
+		}
+	}
+	return nil
+}
+
+// isValidVarType 判断变量类型是否受支持，未指定类型时视为 string
+func isValidVarType(typ string) bool {
+	switch typ {
+	case "", "string", "bool", "int", "float", "list", "map":
+		return true
+	default:
+		return false
+	}
+}
+
+// checkVarType 检查变量值是否与声明的类型匹配
+func checkVarType(typ string, value interface{}) error {
+	ok := false
+	switch typ {
+	case "", "string":
+		_, ok = value.(string)
+	case "bool":
+		_, ok = value.(bool)
+	case "int":
+		switch value.(type) {
+		case int, int64:
+			ok = true
+		}
+	case "float":
+		switch value.(type) {
+		case float64, int, int64:
+			ok = true
+		}
+	case "list":
+		switch value.(type) {
+		case []interface{}, []string:
+			ok = true
+		}
+	case "map":
+		_, ok = value.(map[string]interface{})
+	}
+	if !ok {
+		if typ == "" {
+			typ = "string"
+		}
+		return fmt.Errorf("expected %s, got %T", typ, value)
+	}
+	return nil
+}
+
+// parseVersion 解析形如 v1.2.3 的语义化版本，忽略预发布和构建信息
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	fields := strings.Split(v, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// compareVersions 比较两个版本号，返回 -1、0 或 1
+func compareVersions(a, b [3]int) int {
+	for i := range a {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,156 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeManifest 在模板目录下写入清单文件
+func writeManifest(t *testing.T, dir, content string) {
+	t.Helper()
+	err := os.WriteFile(filepath.Join(dir, ManifestName), []byte(content), 0644)
+	assert.NoError(t, err)
+}
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	// 没有清单文件
+	manifest, err := LoadManifest(dir)
+	assert.NoError(t, err)
+	assert.Nil(t, manifest)
+
+	// 合法的清单文件
+	writeManifest(t, dir, `name: acme
+version: 1.0.0
+minVersion: v0.2.0
+generators:
+  - name: mongo
+    path: mongo
+variables:
+  - name: collection
+    type: string
+    required: true
+  - name: softDelete
+    type: bool
+    default: false
+files:
+  - template: mongo/model.tpl
+    output: "{{.TypeSnake}}.go"
+`)
+	manifest, err = LoadManifest(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", manifest.Name)
+	assert.Equal(t, "v0.2.0", manifest.MinVersion)
+	assert.Len(t, manifest.Generators, 1)
+	assert.Len(t, manifest.Variables, 2)
+	assert.Equal(t, "{{.TypeSnake}}.go", manifest.File("mongo/model.tpl").Output)
+	assert.Nil(t, manifest.File("mongo/other.tpl"))
+}
+
+func TestLoadManifestInvalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{"invalid yaml", "name: [acme"},
+		{"missing name", "version: 1.0.0"},
+		{"missing generator name", "name: acme\ngenerators:\n  - path: mongo"},
+		{"invalid variable type", "name: acme\nvariables:\n  - name: x\n    type: date"},
+		{"default type mismatch", "name: acme\nvariables:\n  - name: x\n    type: bool\n    default: yes please"},
+		{"incomplete file entry", "name: acme\nfiles:\n  - template: model.tpl"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeManifest(t, dir, tc.content)
+			_, err := LoadManifest(dir)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestManifestCheckVersion(t *testing.T) {
+	manifest := &Manifest{Name: "acme", MinVersion: "v1.2.0"}
+
+	assert.NoError(t, manifest.CheckVersion("v1.2.0"))
+	assert.NoError(t, manifest.CheckVersion("1.10.0"))
+	assert.NoError(t, manifest.CheckVersion("v2.0.0-rc.1"))
+	assert.NoError(t, manifest.CheckVersion("dev"))
+	assert.Error(t, manifest.CheckVersion("v1.1.9"))
+
+	assert.NoError(t, (&Manifest{Name: "acme"}).CheckVersion("v0.0.1"))
+	assert.Error(t, (&Manifest{Name: "acme", MinVersion: "latest"}).CheckVersion("v1.0.0"))
+}
+
+func TestManifestGenerator(t *testing.T) {
+	manifest := &Manifest{Name: "acme", Generators: []ManifestGenerator{{Name: "mongo", Path: "mongo"}}}
+
+	generator, err := manifest.Generator("mongo")
+	assert.NoError(t, err)
+	assert.Equal(t, "mongo", generator.Path)
+
+	_, err = manifest.Generator("mysql")
+	assert.Error(t, err)
+
+	// 未声明生成器的清单接受任意生成器
+	generator, err = (&Manifest{Name: "acme"}).Generator("mysql")
+	assert.NoError(t, err)
+	assert.Nil(t, generator)
+}
+
+func TestManifestResolveVars(t *testing.T) {
+	manifest := &Manifest{
+		Name: "acme",
+		Variables: []ManifestVariable{
+			{Name: "collection", Type: "string", Required: true},
+			{Name: "softDelete", Type: "bool", Default: false},
+			{Name: "shards", Type: "int"},
+			{Name: "tags", Type: "list"},
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		vars        map[string]interface{}
+		expected    map[string]interface{}
+		expectError bool
+	}{
+		{
+			name:     "defaults applied",
+			vars:     map[string]interface{}{"collection": "users"},
+			expected: map[string]interface{}{"collection": "users", "softDelete": false},
+		},
+		{
+			name:     "values kept",
+			vars:     map[string]interface{}{"collection": "users", "softDelete": true, "shards": 3, "tags": []interface{}{"a"}, "extra": "x"},
+			expected: map[string]interface{}{"collection": "users", "softDelete": true, "shards": 3, "tags": []interface{}{"a"}, "extra": "x"},
+		},
+		{
+			name:        "missing required",
+			vars:        nil,
+			expectError: true,
+		},
+		{
+			name:        "type mismatch",
+			vars:        map[string]interface{}{"collection": "users", "shards": "three"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vars, err := manifest.ResolveVars(tc.vars)
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, vars)
+			}
+		})
+	}
+}
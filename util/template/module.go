@@ -0,0 +1,78 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// moduleDownload is the output of go mod download -json
+type moduleDownload struct {
+	Path    string // Module path
+	Version string // Resolved version
+	Error   string // Error downloading the module
+	Dir     string // Extracted module in the module cache
+	Sum     string // Checksum of the module, as in go.sum
+}
+
+// isGoModule checks if a template source is a Go module path such as
+// github.com/org/templates@v1.2.0. The first element of a module path is a
+// domain name; existing local paths take precedence
+func isGoModule(source string) bool {
+	if strings.Contains(source, "://") || strings.HasPrefix(source, "git@") || strings.HasPrefix(source, ".") ||
+		filepath.IsAbs(source) || isArchive(source) {
+		return false
+	}
+	if _, err := os.Stat(source); err == nil {
+		return false
+	}
+	modPath, _, _ := parseGitSource(source)
+	first, _, _ := strings.Cut(modPath, "/")
+	return strings.Contains(first, ".") && !strings.ContainsAny(modPath, `\:`)
+}
+
+// getModuleTemplate downloads a Go module into the module cache with go mod download
+// and returns its directory. The go command resolves the module through GOPROXY and
+// verifies it against the checksum database unless GOPRIVATE or GONOSUMDB exclude it.
+// A non-empty checksum must match the h1: hash of the module as in go.sum.
+// In offline mode only the module cache is used
+func getModuleTemplate(modPath, version, checksum string, offline bool) (string, error) {
+	if version == "" {
+		version = "latest"
+	}
+	if checksum != "" && !strings.HasPrefix(checksum, "h1:") {
+		return "", fmt.Errorf("invalid template checksum %q, expected h1:<hash> for a Go module", checksum)
+	}
+
+	query := modPath + "@" + version
+	cmd := defaultCommander.Command("go", "mod", "download", "-json", query)
+	if offline {
+		cmd.Env = append(cmd.Environ(), "GOPROXY=off")
+	}
+	output, err := cmd.Output()
+
+	// Failures are reported in the JSON output as well
+	var download moduleDownload
+	if jsonErr := json.Unmarshal(output, &download); jsonErr != nil {
+		if err != nil {
+			return "", fmt.Errorf("go mod download %s: %w", query, err)
+		}
+		return "", fmt.Errorf("parse go mod download output: %w", jsonErr)
+	}
+	if download.Error != "" {
+		return "", fmt.Errorf("go mod download %s: %s", query, download.Error)
+	}
+	if err != nil {
+		return "", fmt.Errorf("go mod download %s: %w", query, err)
+	}
+	if download.Dir == "" {
+		return "", fmt.Errorf("go mod download %s: no module directory", query)
+	}
+
+	if checksum != "" && download.Sum != checksum {
+		return "", fmt.Errorf("checksum mismatch for %s@%s: got %s, expected %s", modPath, download.Version, download.Sum, checksum)
+	}
+	return download.Dir, nil
+}
@@ -0,0 +1,176 @@
+package template
+
+import (
+	"archive/zip"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/lewinz/go-gen/util/naming"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsGoModule(t *testing.T) {
+	localDir := t.TempDir()
+
+	testCases := []struct {
+		name     string
+		source   string
+		expected bool
+	}{
+		{"module with version", "github.com/org/templates@v1.2.0", true},
+		{"module without version", "github.com/org/templates", true},
+		{"module with subdir", "example.com/templates//mongo@v1.2.0", true},
+		{"builtin", BuiltinTemplate, false},
+		{"relative path", "./templates", false},
+		{"path without domain", "templates/mongo", false},
+		{"existing local path", localDir, false},
+		{"git repository", "https://github.com/org/templates", false},
+		{"ssh repository", "git@github.com:org/templates.git", false},
+		{"archive", "example.com/templates.tar.gz", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isGoModule(tc.source))
+		})
+	}
+}
+
+func TestGetModuleTemplate(t *testing.T) {
+	// 保存原始的命令执行器
+	oldCommander := defaultCommander
+	defer func() { defaultCommander = oldCommander }()
+
+	testCases := []struct {
+		name          string
+		modPath       string
+		version       string
+		checksum      string
+		expectedDir   string
+		expectedCalls [][]string
+		expectError   string
+	}{
+		{
+			name:        "pinned version",
+			modPath:     "github.com/org/templates",
+			version:     "v1.2.0",
+			expectedDir: "/modcache/github.com/org/templates@v1.2.0",
+			expectedCalls: [][]string{
+				{"go", "mod", "download", "-json", "github.com/org/templates@v1.2.0"},
+			},
+		},
+		{
+			name:        "latest version",
+			modPath:     "github.com/org/templates",
+			expectedDir: "/modcache/github.com/org/templates@v1.2.0",
+			expectedCalls: [][]string{
+				{"go", "mod", "download", "-json", "github.com/org/templates@latest"},
+			},
+		},
+		{
+			name:        "matching checksum",
+			modPath:     "github.com/org/templates",
+			version:     "v1.2.0",
+			checksum:    "h1:abc=",
+			expectedDir: "/modcache/github.com/org/templates@v1.2.0",
+			expectedCalls: [][]string{
+				{"go", "mod", "download", "-json", "github.com/org/templates@v1.2.0"},
+			},
+		},
+		{
+			name:        "checksum mismatch",
+			modPath:     "github.com/org/templates",
+			version:     "v1.2.0",
+			checksum:    "h1:xyz=",
+			expectError: "checksum mismatch",
+		},
+		{
+			name:        "archive checksum",
+			modPath:     "github.com/org/templates",
+			version:     "v1.2.0",
+			checksum:    "sha256:abc",
+			expectError: "expected h1:<hash> for a Go module",
+		},
+		{
+			name:        "missing module",
+			modPath:     "github.com/org/missing",
+			version:     "v1.2.0",
+			expectError: "go mod download github.com/org/missing@v1.2.0: not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commander := &MockCommander{}
+			defaultCommander = commander
+			dir, err := getModuleTemplate(tc.modPath, tc.version, tc.checksum, false)
+			if tc.expectError != "" {
+				assert.ErrorContains(t, err, tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDir, dir)
+			assert.Equal(t, tc.expectedCalls, commander.calls)
+		})
+	}
+}
+
+func TestGenerateWithModule(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+
+	// 创建本地模块代理，通过 GOPROXY=file:// 使用真实的 go 命令
+	proxyDir := t.TempDir()
+	versionDir := filepath.Join(proxyDir, "example.com", "templates", "@v")
+	err := os.MkdirAll(versionDir, 0755)
+	assert.NoError(t, err)
+	files := map[string]string{
+		"list":        "v1.2.0\n",
+		"v1.2.0.info": `{"Version":"v1.2.0","Time":"2024-01-01T00:00:00Z"}`,
+		"v1.2.0.mod":  "module example.com/templates\n",
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(versionDir, name), []byte(content), 0644)
+		assert.NoError(t, err)
+	}
+	zipFile, err := os.Create(filepath.Join(versionDir, "v1.2.0.zip"))
+	assert.NoError(t, err)
+	zw := zip.NewWriter(zipFile)
+	for name, content := range map[string]string{
+		"go.mod":          "module example.com/templates\n",
+		"mongo/model.tpl": "package {{.PackageName}}\n",
+	} {
+		w, err := zw.Create("example.com/templates@v1.2.0/" + name)
+		assert.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, zipFile.Close())
+
+	t.Setenv("GOPROXY", "file://"+filepath.ToSlash(proxyDir))
+	t.Setenv("GOMODCACHE", t.TempDir())
+	t.Setenv("GOFLAGS", "-modcacherw")
+	t.Setenv("GOSUMDB", "off")
+
+	outputDir := filepath.Join(t.TempDir(), "model")
+	err = os.MkdirAll(outputDir, 0755)
+	assert.NoError(t, err)
+
+	engine := NewEngine(naming.StyleSnake)
+	err = engine.Generate("example.com/templates//mongo@v1.2.0", outputDir, "user")
+	assert.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(outputDir, "user_model.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package model\n", string(content))
+
+	// 离线模式只使用模块缓存
+	engine = NewEngine(naming.StyleSnake, WithOffline(true), WithPath("mongo"))
+	err = engine.Generate("example.com/templates@v1.2.0", outputDir, "user")
+	assert.NoError(t, err)
+	err = engine.Generate("example.com/other@v1.0.0", outputDir, "user")
+	assert.ErrorContains(t, err, "GOPROXY=off")
+}
@@ -0,0 +1,97 @@
+package template
+
+import (
+	"fmt"
+	"go/token"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/lewinz/go-gen/util/naming"
+)
+
+// outputName 计算模板文件相对于输出目录的输出路径
+//
+// 模板所在的子目录结构会被保留，目录名和文件名都可以包含模板语法，
+// 例如 internal/{{.TypeSnake}}/handler.tpl 会生成 internal/user/handler.go。
+// 文件名不包含模板语法时沿用 类型名_模板名 的命名规则，输出扩展名由双后缀推断，
+// 例如 model.sql.tpl 生成 user_model.sql。模型的测试模板测试的是整个类型，
+// model_test.tpl 和 model_test.go.tpl 都生成 user_test.go，其他测试模板保留模板名，
+// 例如 repo_test.tpl 生成 user_repo_test.go
+func (e *Engine) outputName(relPath string, data *TemplateData, opts fileOptions) (string, error) {
+	dir, name := path.Split(filepath.ToSlash(relPath))
+
+	// 渲染目录
+	renderedDir, err := renderString(dir, data)
+	if err != nil {
+		return "", fmt.Errorf("render output directory %s: %w", dir, err)
+	}
+
+	name = strings.TrimSuffix(name, ".tpl") // 去掉 .tpl 后缀
+	if strings.Contains(name, "{{") {
+		// 文件名本身是模板，渲染后直接使用
+		rendered, err := renderString(name, data)
+		if err != nil {
+			return "", fmt.Errorf("render output name %s: %w", name, err)
+		}
+		name = rendered
+		if path.Ext(name) == "" {
+			name = name + ".go"
+		}
+	} else {
+		// 推断输出扩展名，没有双后缀时默认为 .go
+		ext := path.Ext(name)
+		name = strings.TrimSuffix(name, ext)
+		if ext == "" {
+			ext = ".go"
+		}
+		// Go 测试文件必须以 _test.go 结尾，不参与命名风格转换
+		testSuffix := ""
+		if ext == ".go" && strings.HasSuffix(name, "_test") {
+			name = strings.TrimSuffix(name, "_test")
+			testSuffix = "_test"
+		}
+		switch {
+		case opts.prefix && testSuffix != "" && name == "model":
+			// 模型的测试文件以类型名命名，例如：model_test -> user_test
+			name = data.Type
+		case opts.prefix:
+			// 组合类型名和模板名，并确保它们之间有分隔符
+			name = data.Type + "_" + name // 例如：user + _ + model = user_model
+		}
+		// 根据指定的命名风格转换
+		converter := naming.NewConverter(e.fileStyle)
+		name = converter.Convert(name) // 例如：user_model -> userModel
+		name = name + testSuffix + ext // 添加扩展名
+	}
+
+	return cleanOutputName(path.Join(renderedDir, name))
+}
+
+// cleanOutputName 规范化输出路径，并确保它不会逃逸出输出目录
+func cleanOutputName(name string) (string, error) {
+	name = path.Clean(filepath.ToSlash(name))
+	if name == "." || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", fmt.Errorf("invalid output path %q", name)
+	}
+	return filepath.FromSlash(name), nil
+}
+
+// packageName 将目录名转换为合法的 Go 包名
+//
+// 去掉字母、数字和下划线以外的字符并转为小写，例如 user-profile -> userprofile。
+// 结果为空、以数字开头或是关键字时添加 pkg 前缀，例如 2fa -> pkg2fa
+func packageName(dir string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(dir) {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	name := b.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) || token.IsKeyword(name) {
+		name = "pkg" + name
+	}
+	return name
+}
@@ -0,0 +1,127 @@
+package template
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/lewinz/go-gen/util/naming"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputName(t *testing.T) {
+	data := &TemplateData{Type: "UserProfile", TypeSnake: "user_profile", TypeKebab: "user-profile"}
+
+	testCases := []struct {
+		name        string
+		style       naming.Style
+		relPath     string
+		expected    string
+		expectError bool
+	}{
+		{"flat snake", naming.StyleSnake, "model.tpl", "user_profile_model.go", false},
+		{"flat camel", naming.StyleCamel, "model.tpl", "userProfileModel.go", false},
+		{"subdirectory kept", naming.StyleSnake, "repo/model.tpl", "repo/user_profile_model.go", false},
+		{"templated directory", naming.StyleSnake, "internal/{{.TypeSnake}}/handler.tpl", "internal/user_profile/user_profile_handler.go", false},
+		{"templated kebab directory", naming.StyleSnake, "{{.TypeKebab}}/model.tpl", "user-profile/user_profile_model.go", false},
+		{"templated file name", naming.StyleSnake, "{{.TypeSnake}}.tpl", "user_profile.go", false},
+		{"templated file name with extension", naming.StyleSnake, "{{.TypeSnake}}_handler.go.tpl", "user_profile_handler.go", false},
+		{"sql extension", naming.StyleSnake, "model.sql.tpl", "user_profile_model.sql", false},
+		{"yaml extension camel", naming.StyleCamel, "fixture.yaml.tpl", "userProfileFixture.yaml", false},
+		{"go test double suffix", naming.StyleSnake, "model_test.go.tpl", "user_profile_test.go", false},
+		{"go test camel keeps suffix", naming.StyleCamel, "model_test.go.tpl", "userProfile_test.go", false},
+		{"go test without double suffix", naming.StyleSnake, "model_test.tpl", "user_profile_test.go", false},
+		{"go test in subdirectory", naming.StyleSnake, "repo/model_test.tpl", "repo/user_profile_test.go", false},
+		{"go test of another template", naming.StyleSnake, "repo/repo_test.tpl", "repo/user_profile_repo_test.go", false},
+		{"go test of another template camel", naming.StyleCamel, "repo_test.go.tpl", "userProfileRepo_test.go", false},
+		{"invalid directory template", naming.StyleSnake, "{{.Missing}}/model.tpl", "", true},
+		{"escaping output path", naming.StyleSnake, "../{{.TypeSnake}}.tpl", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			engine := NewEngine(tc.style)
+			result, err := engine.outputName(tc.relPath, data, defaultFileOptions())
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, filepath.FromSlash(tc.expected), result)
+			}
+		})
+	}
+}
+
+func TestCleanOutputName(t *testing.T) {
+	testCases := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{"simple", "user.go", "user.go", false},
+		{"nested", "internal/user/handler.go", "internal/user/handler.go", false},
+		{"redundant", "./internal//user/../user/handler.go", "internal/user/handler.go", false},
+		{"parent", "../user.go", "", true},
+		{"absolute", "/etc/user.go", "", true},
+		{"empty", "", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := cleanOutputName(tc.input)
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, filepath.FromSlash(tc.expected), result)
+			}
+		})
+	}
+}
+
+func TestOutputNameWithoutPrefix(t *testing.T) {
+	data := &TemplateData{Type: "UserProfile"}
+	opts := fileOptions{prefix: false}
+
+	testCases := []struct {
+		name     string
+		style    naming.Style
+		relPath  string
+		expected string
+	}{
+		{"go file", naming.StyleSnake, "doc.tpl", "doc.go"},
+		{"markdown", naming.StyleSnake, "README.md.tpl", "readme.md"},
+		{"proto", naming.StylePascal, "service.proto.tpl", "Service.proto"},
+		{"migration", naming.StyleSnake, "migrations/create_table.sql.tpl", "migrations/create_table.sql"},
+		{"test file", naming.StyleSnake, "main_test.go.tpl", "main_test.go"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := NewEngine(tc.style).outputName(tc.relPath, data, opts)
+			assert.NoError(t, err)
+			assert.Equal(t, filepath.FromSlash(tc.expected), result)
+		})
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	testCases := []struct {
+		dir      string
+		expected string
+	}{
+		{"user", "user"},
+		{"user-profile", "userprofile"},
+		{"User.Profile", "userprofile"},
+		{"user_profile", "user_profile"},
+		{"2fa", "pkg2fa"},
+		{"type", "pkgtype"},
+		{"---", "pkg"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.dir, func(t *testing.T) {
+			assert.Equal(t, tc.expected, packageName(tc.dir))
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package template
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const (
+	partialDir    = "_partials"    // 公共片段目录
+	partialSuffix = ".partial.tpl" // 公共片段文件后缀
+)
+
+// isPartial 判断模板文件是否为公共片段
+//
+// 位于 _partials 目录下的文件，或以 .partial.tpl 结尾的文件被视为公共片段，
+// 它们只会被加载到共享模板集合中，不会生成输出文件
+func isPartial(relPath string) bool {
+	if strings.HasSuffix(relPath, partialSuffix) {
+		return true
+	}
+	for _, part := range strings.Split(filepath.ToSlash(relPath), "/") {
+		if part == partialDir {
+			return true
+		}
+	}
+	return false
+}
+
+// loadPartials 加载生成器目录 dir 及模板包根目录 _partials 下的公共片段，返回共享模板集合
+//
+// 每个模板在渲染前都会克隆该集合，因此片段中 {{define}} 的子模板
+// 可以在任意模板中通过 {{template "name" .}} 引用。其他生成器目录下的片段
+// 不会被加载，避免同名片段相互覆盖
+func loadPartials(fsys fs.FS, dir string) (*template.Template, error) {
+	set := template.New("").Funcs(FuncMap())
+
+	dirs := []string{dir}
+	if dir != "." {
+		if info, err := fs.Stat(fsys, partialDir); err == nil && info.IsDir() {
+			dirs = append([]string{partialDir}, dirs...)
+		}
+	}
+	for _, root := range dirs {
+		err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.HasSuffix(d.Name(), ".tpl") || !isPartial(name) {
+				return nil
+			}
+
+			content, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return fmt.Errorf("read partial %s: %w", name, err)
+			}
+			if _, err := set.New(name).Parse(string(content)); err != nil {
+				return fmt.Errorf("parse partial %s: %w", name, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return set, nil
+}
@@ -0,0 +1,95 @@
+package template
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPartial(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     string
+		expected bool
+	}{
+		{"partial dir", "_partials/header.tpl", true},
+		{"nested partial dir", "mongo/_partials/header.tpl", true},
+		{"partial suffix", "header.partial.tpl", true},
+		{"nested partial suffix", "mongo/header.partial.tpl", true},
+		{"regular template", "model.tpl", false},
+		{"nested regular template", "mongo/model.tpl", false},
+		{"similar dir name", "partials/header.tpl", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isPartial(tc.path))
+		})
+	}
+}
+
+func TestLoadPartials(t *testing.T) {
+	templateDir := t.TempDir()
+
+	// 创建公共片段
+	err := os.MkdirAll(filepath.Join(templateDir, "_partials"), 0755)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(templateDir, "_partials", "header.tpl"),
+		[]byte(`{{define "header"}}// Code generated for {{.}}.{{end}}`), 0644)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(templateDir, "errors.partial.tpl"),
+		[]byte(`{{define "errors"}}var ErrNotFound = errors.New("not found"){{end}}`), 0644)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(templateDir, "model.tpl"), []byte(`{{define "model"}}{{end}}`), 0644)
+	assert.NoError(t, err)
+
+	set, err := loadPartials(os.DirFS(templateDir), ".")
+	assert.NoError(t, err)
+	assert.NotNil(t, set.Lookup("header"))
+	assert.NotNil(t, set.Lookup("errors"))
+	assert.Nil(t, set.Lookup("model"))
+
+	var buf bytes.Buffer
+	err = set.ExecuteTemplate(&buf, "header", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, "// Code generated for user.", buf.String())
+}
+
+func TestLoadPartialsWithInvalidContent(t *testing.T) {
+	templateDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(templateDir, "broken.partial.tpl"), []byte(`{{define "x"}}`), 0644)
+	assert.NoError(t, err)
+
+	_, err = loadPartials(os.DirFS(templateDir), ".")
+	assert.Error(t, err)
+}
+
+func TestLoadPartialsOfGenerator(t *testing.T) {
+	templateDir := t.TempDir()
+	files := map[string]string{
+		"_partials/common.tpl":   `{{define "common"}}common{{end}}`,
+		"mongo/_partials/h.tpl":  `{{define "header"}}// MONGO header{{end}}`,
+		"mysql/_partials/h.tpl":  `{{define "header"}}// MYSQL header{{end}}`,
+		"mysql/only.partial.tpl": `{{define "mysql"}}mysql{{end}}`,
+	}
+	for name, content := range files {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(templateDir, name)), 0755)
+		assert.NoError(t, err)
+		err = os.WriteFile(filepath.Join(templateDir, name), []byte(content), 0644)
+		assert.NoError(t, err)
+	}
+
+	// 只加载生成器目录和根目录 _partials 下的片段
+	set, err := loadPartials(os.DirFS(templateDir), "mongo")
+	assert.NoError(t, err)
+	assert.NotNil(t, set.Lookup("common"))
+	assert.Nil(t, set.Lookup("mysql"))
+
+	var buf bytes.Buffer
+	err = set.ExecuteTemplate(&buf, "header", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "// MONGO header", buf.String())
+}
@@ -0,0 +1,100 @@
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// regionPattern 匹配受保护区域的标记注释，例如 // go-gen:begin fields
+//
+// 只匹配标记文本本身，因此可以用于任意注释语法，例如 SQL 中的 -- go-gen:begin indexes
+var regionPattern = regexp.MustCompile(`go-gen:(begin|end)\s+(\S+)`)
+
+// region 受保护区域
+type region struct {
+	name  string // 区域名称
+	begin int    // 开始标记所在行
+	end   int    // 结束标记所在行
+}
+
+// parseRegions 按出现顺序解析文本中的受保护区域，区域不能嵌套且名称不能重复
+func parseRegions(lines []string) ([]region, error) {
+	var regions []region
+	var open *region
+	seen := make(map[string]bool)
+	for i, line := range lines {
+		match := regionPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		kind, name := match[1], match[2]
+		switch {
+		case kind == "begin" && open != nil:
+			return nil, fmt.Errorf("line %d: region %q begins inside region %q", i+1, name, open.name)
+		case kind == "begin" && seen[name]:
+			return nil, fmt.Errorf("line %d: duplicate region %q", i+1, name)
+		case kind == "begin":
+			seen[name] = true
+			open = &region{name: name, begin: i}
+		case open == nil || open.name != name:
+			return nil, fmt.Errorf("line %d: unexpected end of region %q", i+1, name)
+		default:
+			open.end = i
+			regions = append(regions, *open)
+			open = nil
+		}
+	}
+	if open != nil {
+		return nil, fmt.Errorf("line %d: region %q is not closed", open.begin+1, open.name)
+	}
+	return regions, nil
+}
+
+// mergeRegions 将已有文件中受保护区域的内容放回新渲染的结果中
+//
+// 新结果中的区域使用已有文件中同名区域的内容，已有文件中没有的区域保留模板中的默认内容。
+// 返回已有文件中存在、但新结果中已经没有的区域名称，这些区域的内容不会被保留
+func mergeRegions(rendered, existing []byte) ([]byte, []string, error) {
+	newLines := strings.SplitAfter(string(rendered), "\n")
+	newRegions, err := parseRegions(newLines)
+	if err != nil {
+		return nil, nil, fmt.Errorf("rendered output: %w", err)
+	}
+	oldLines := strings.SplitAfter(string(existing), "\n")
+	oldRegions, err := parseRegions(oldLines)
+	if err != nil {
+		return nil, nil, fmt.Errorf("existing file: %w", err)
+	}
+	if len(oldRegions) == 0 {
+		return rendered, nil, nil
+	}
+
+	// 已有文件中各区域的内容
+	contents := make(map[string][]string, len(oldRegions))
+	for _, r := range oldRegions {
+		contents[r.name] = oldLines[r.begin+1 : r.end]
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, r := range newRegions {
+		content, ok := contents[r.name]
+		if !ok {
+			continue
+		}
+		delete(contents, r.name)
+		out.WriteString(strings.Join(newLines[last:r.begin+1], ""))
+		out.WriteString(strings.Join(content, ""))
+		last = r.end
+	}
+	out.WriteString(strings.Join(newLines[last:], ""))
+
+	var dropped []string
+	for _, r := range oldRegions {
+		if _, ok := contents[r.name]; ok {
+			dropped = append(dropped, r.name)
+		}
+	}
+	return []byte(out.String()), dropped, nil
+}
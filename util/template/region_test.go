@@ -0,0 +1,101 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRegions(t *testing.T) {
+	testCases := []struct {
+		name        string
+		content     string
+		expected    []region
+		expectError bool
+	}{
+		{"no regions", "package model\n", nil, false},
+		{"single region", "a\n// go-gen:begin fields\nb\n// go-gen:end fields\n", []region{{name: "fields", begin: 1, end: 3}}, false},
+		{"other comment syntax", "-- go-gen:begin indexes\n-- go-gen:end indexes\n", []region{{name: "indexes", begin: 0, end: 1}}, false},
+		{"two regions", "// go-gen:begin a\n// go-gen:end a\n// go-gen:begin b\n// go-gen:end b\n", []region{{name: "a", begin: 0, end: 1}, {name: "b", begin: 2, end: 3}}, false},
+		{"not closed", "// go-gen:begin fields\n", nil, true},
+		{"nested", "// go-gen:begin a\n// go-gen:begin b\n// go-gen:end b\n// go-gen:end a\n", nil, true},
+		{"mismatched end", "// go-gen:begin a\n// go-gen:end b\n", nil, true},
+		{"end without begin", "// go-gen:end a\n", nil, true},
+		{"duplicate", "// go-gen:begin a\n// go-gen:end a\n// go-gen:begin a\n// go-gen:end a\n", nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			regions, err := parseRegions(strings.SplitAfter(tc.content, "\n"))
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, regions)
+			}
+		})
+	}
+}
+
+func TestMergeRegions(t *testing.T) {
+	testCases := []struct {
+		name            string
+		rendered        string
+		existing        string
+		expected        string
+		expectedDropped []string
+		expectError     bool
+	}{
+		{
+			name:     "keeps existing content",
+			rendered: "type User struct {\n\t// go-gen:begin fields\n\t// Add your fields here\n\t// go-gen:end fields\n\tName string\n}\n",
+			existing: "type User struct {\n\t// go-gen:begin fields\n\tAge int\n\tTags []string\n\t// go-gen:end fields\n}\n",
+			expected: "type User struct {\n\t// go-gen:begin fields\n\tAge int\n\tTags []string\n\t// go-gen:end fields\n\tName string\n}\n",
+		},
+		{
+			name:     "keeps template default for new regions",
+			rendered: "// go-gen:begin a\ndefault a\n// go-gen:end a\n// go-gen:begin b\ndefault b\n// go-gen:end b\n",
+			existing: "// go-gen:begin b\ncustom b\n// go-gen:end b\n",
+			expected: "// go-gen:begin a\ndefault a\n// go-gen:end a\n// go-gen:begin b\ncustom b\n// go-gen:end b\n",
+		},
+		{
+			name:     "empty region",
+			rendered: "// go-gen:begin a\ndefault\n// go-gen:end a\n",
+			existing: "// go-gen:begin a\n// go-gen:end a\n",
+			expected: "// go-gen:begin a\n// go-gen:end a\n",
+		},
+		{
+			name:     "existing file without regions",
+			rendered: "// go-gen:begin a\ndefault\n// go-gen:end a\n",
+			existing: "package model\n",
+			expected: "// go-gen:begin a\ndefault\n// go-gen:end a\n",
+		},
+		{
+			name:            "reports dropped regions",
+			rendered:        "package model\n",
+			existing:        "// go-gen:begin old\ncustom\n// go-gen:end old\n",
+			expected:        "package model\n",
+			expectedDropped: []string{"old"},
+		},
+		{
+			name:        "broken existing file",
+			rendered:    "// go-gen:begin a\n// go-gen:end a\n",
+			existing:    "// go-gen:begin a\ncustom\n",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			merged, dropped, err := mergeRegions([]byte(tc.rendered), []byte(tc.existing))
+			if tc.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, string(merged))
+				assert.Equal(t, tc.expectedDropped, dropped)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadValues 读取 YAML 或 JSON 格式的变量文件，顶层必须是映射
+func LoadValues(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read values: %w", err)
+	}
+	var vars map[string]interface{}
+	if err := yaml.Unmarshal(content, &vars); err != nil {
+		return nil, fmt.Errorf("parse values %s: %w", path, err)
+	}
+	return vars, nil
+}
+
+// MergeVars 将 src 中的变量合并到 dst 并返回 dst，两边都是映射的变量逐层合并，其余变量由 src 覆盖
+func MergeVars(dst, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = make(map[string]interface{}, len(src))
+	}
+	for k, v := range src {
+		if srcMap, ok := v.(map[string]interface{}); ok {
+			if dstMap, ok := dst[k].(map[string]interface{}); ok {
+				dst[k] = MergeVars(dstMap, srcMap)
+				continue
+			}
+			v = MergeVars(nil, srcMap)
+		}
+		dst[k] = v
+	}
+	return dst
+}
+
+// SetVar 解析 key=value 形式的变量并写入 vars
+//
+// key 中的 . 表示嵌套的映射，例如 db.name=app。值按 YAML 解析，因此 true、42、[a, b] 和 {k: v}
+// 分别得到布尔值、整数、列表和映射；需要字符串时加引号，例如 version="1.0"
+func SetVar(vars map[string]interface{}, expr string) error {
+	key, raw, ok := strings.Cut(expr, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("invalid variable %q, expected key=value", expr)
+	}
+	value, err := parseValue(raw)
+	if err != nil {
+		return fmt.Errorf("variable %s: %w", key, err)
+	}
+
+	parts := strings.Split(key, ".")
+	for i, part := range parts[:len(parts)-1] {
+		if part == "" {
+			return fmt.Errorf("invalid variable name %q", key)
+		}
+		next, ok := vars[part]
+		if !ok || next == nil {
+			next = make(map[string]interface{})
+			vars[part] = next
+		}
+		nested, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("variable %s: %s is not a map", key, strings.Join(parts[:i+1], "."))
+		}
+		vars = nested
+	}
+	last := parts[len(parts)-1]
+	if last == "" {
+		return fmt.Errorf("invalid variable name %q", key)
+	}
+	vars[last] = value
+	return nil
+}
+
+// parseValue 按 YAML 解析变量值
+//
+// 只有标量和以 [ 或 { 开头的流式集合按 YAML 解析，其他文本（例如包含冒号的句子）保持为字符串
+func parseValue(raw string) (interface{}, error) {
+	flow := strings.HasPrefix(raw, "[") || strings.HasPrefix(raw, "{")
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(raw), &node); err != nil || len(node.Content) == 0 {
+		if flow {
+			return nil, fmt.Errorf("parse %q: %w", raw, err)
+		}
+		return raw, nil
+	}
+	if content := node.Content[0]; content.Kind == yaml.ScalarNode || flow {
+		var value interface{}
+		if err := content.Decode(&value); err != nil {
+			return nil, fmt.Errorf("parse %q: %w", raw, err)
+		}
+		if value == nil {
+			return raw, nil
+		}
+		return value, nil
+	}
+	return raw, nil
+}
@@ -0,0 +1,148 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetVar(t *testing.T) {
+	testCases := []struct {
+		name        string
+		expr        string
+		key         string
+		expected    interface{}
+		expectError string
+	}{
+		{"string", "collection=users", "collection", "users", ""},
+		{"bool", "soft_delete=true", "soft_delete", true, ""},
+		{"int", "replicas=3", "replicas", 3, ""},
+		{"float", "ratio=0.5", "ratio", 0.5, ""},
+		{"quoted string", `version="1.0"`, "version", "1.0", ""},
+		{"empty string", "owner=", "owner", "", ""},
+		{"list", "tags=[a, b]", "tags", []interface{}{"a", "b"}, ""},
+		{"map", "team={name: core, size: 4}", "team", map[string]interface{}{"name": "core", "size": 4}, ""},
+		{"sentence with colon", "note=todo: add index", "note", "todo: add index", ""},
+		{"value with equals sign", "filter=a=b", "filter", "a=b", ""},
+		{"comment", "issue=#12", "issue", "#12", ""},
+		{"missing value", "collection", "", nil, "expected key=value"},
+		{"empty key", "=users", "", nil, "expected key=value"},
+		{"invalid list", "tags=[a, b", "", nil, "parse"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vars := map[string]interface{}{}
+			err := SetVar(vars, tc.expr)
+			if tc.expectError != "" {
+				assert.ErrorContains(t, err, tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, vars[tc.key])
+		})
+	}
+}
+
+func TestSetVarNested(t *testing.T) {
+	vars := map[string]interface{}{
+		"db":         map[string]interface{}{"name": "app"},
+		"collection": "users",
+	}
+
+	// . 表示嵌套的映射，不存在的映射会被创建
+	assert.NoError(t, SetVar(vars, "db.host=localhost"))
+	assert.NoError(t, SetVar(vars, "team.owner.name=core"))
+	assert.Equal(t, map[string]interface{}{"name": "app", "host": "localhost"}, vars["db"])
+	assert.Equal(t, map[string]interface{}{"owner": map[string]interface{}{"name": "core"}}, vars["team"])
+
+	// 不能在非映射的变量下设置
+	assert.ErrorContains(t, SetVar(vars, "collection.name=accounts"), "collection is not a map")
+	assert.ErrorContains(t, SetVar(vars, "db..name=app"), "invalid variable name")
+	assert.ErrorContains(t, SetVar(vars, "db.=app"), "invalid variable name")
+}
+
+func TestLoadValues(t *testing.T) {
+	dir := t.TempDir()
+
+	testCases := []struct {
+		name        string
+		content     string
+		expected    map[string]interface{}
+		expectError string
+	}{
+		{
+			name:    "yaml",
+			content: "collection: users\nsoft_delete: true\ntags: [a, b]\ndb:\n  name: app\n",
+			expected: map[string]interface{}{
+				"collection":  "users",
+				"soft_delete": true,
+				"tags":        []interface{}{"a", "b"},
+				"db":          map[string]interface{}{"name": "app"},
+			},
+		},
+		{
+			name:     "json",
+			content:  `{"collection": "users", "replicas": 3}`,
+			expected: map[string]interface{}{"collection": "users", "replicas": 3},
+		},
+		{
+			name:     "empty",
+			content:  "",
+			expected: nil,
+		},
+		{
+			name:        "not a map",
+			content:     "- a\n- b\n",
+			expectError: "parse values",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, "values.yaml")
+			err := os.WriteFile(path, []byte(tc.content), 0644)
+			assert.NoError(t, err)
+
+			vars, err := LoadValues(path)
+			if tc.expectError != "" {
+				assert.ErrorContains(t, err, tc.expectError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, vars)
+		})
+	}
+
+	_, err := LoadValues(filepath.Join(dir, "missing.yaml"))
+	assert.ErrorContains(t, err, "read values")
+}
+
+func TestMergeVars(t *testing.T) {
+	dst := map[string]interface{}{
+		"collection": "users",
+		"db":         map[string]interface{}{"name": "app", "host": "localhost"},
+		"tags":       []interface{}{"a"},
+	}
+	src := map[string]interface{}{
+		"db":   map[string]interface{}{"host": "mongo"},
+		"tags": []interface{}{"b"},
+		"team": map[string]interface{}{"name": "core"},
+	}
+
+	result := MergeVars(dst, src)
+	assert.Equal(t, map[string]interface{}{
+		"collection": "users",
+		"db":         map[string]interface{}{"name": "app", "host": "mongo"},
+		"tags":       []interface{}{"b"},
+		"team":       map[string]interface{}{"name": "core"},
+	}, result)
+
+	// 合并结果不与 src 共享映射
+	result["team"].(map[string]interface{})["name"] = "platform"
+	assert.Equal(t, "core", src["team"].(map[string]interface{})["name"])
+
+	assert.Equal(t, map[string]interface{}{"a": 1}, MergeVars(nil, map[string]interface{}{"a": 1}))
+}
@@ -0,0 +1,167 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// 文件变更状态
+const (
+	statusCreate    = "create"    // 文件不存在，将被创建
+	statusModify    = "modify"    // 文件已存在且内容不同，将被覆盖
+	statusUnchanged = "unchanged" // 文件已存在且内容相同
+	statusSkip      = "skip"      // 文件已存在且内容不同，按冲突处理策略保留
+	statusConflict  = "conflict"  // 文件已存在且内容不同，按冲突处理策略将报错
+)
+
+// renderedFile 渲染完成、等待写入的输出文件
+type renderedFile struct {
+	path       string         // 输出文件路径
+	content    []byte         // 渲染后的内容
+	onConflict ConflictPolicy // 模板指令中指定的冲突处理策略
+}
+
+// pendingWrite 确定需要写入的输出文件
+type pendingWrite struct {
+	file     renderedFile
+	existing []byte // 已有文件的内容
+	backup   bool   // 写入前是否备份已有文件
+}
+
+// writeFiles 将渲染结果写入输出目录
+//
+// 先与已有文件比较并按冲突处理策略确定每个文件的处理方式，全部确定后再统一写入，
+// 因此 fail 策略不会留下写了一半的结果。
+// 开启 dry-run 或 diff 时只输出预览结果，不修改磁盘上的任何文件
+func (e *Engine) writeFiles(outputDir string, files []renderedFile) error {
+	preview := e.dryRun || e.diff
+
+	var writes []pendingWrite
+	for _, file := range files {
+		existing, err := os.ReadFile(file.path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("read existing file %s: %w", file.path, err)
+		}
+		status := fileStatus(existing, err == nil, file.content)
+
+		name := file.path
+		if rel, err := filepath.Rel(outputDir, file.path); err == nil {
+			name = filepath.ToSlash(rel)
+		}
+
+		// 按冲突处理策略处理内容不同的已有文件
+		policy := e.conflictPolicy(file)
+		if status == statusModify {
+			switch policy {
+			case ConflictSkip:
+				status = statusSkip
+			case ConflictFail:
+				if !preview {
+					return fmt.Errorf("output file %s already exists", file.path)
+				}
+				status = statusConflict
+			case ConflictPrompt:
+				if !preview {
+					ok, err := e.confirmOverwrite(name)
+					if err != nil {
+						return err
+					}
+					if !ok {
+						status = statusSkip
+					}
+				}
+			}
+		}
+
+		if preview {
+			if e.dryRun {
+				fmt.Fprintf(e.out, "%-9s %s\n", status, name)
+			}
+			if e.diff && (status == statusCreate || status == statusModify) {
+				text, err := unifiedDiff(name, existing, file.content, status == statusCreate)
+				if err != nil {
+					return fmt.Errorf("diff %s: %w", file.path, err)
+				}
+				fmt.Fprint(e.out, text)
+			}
+			continue
+		}
+
+		switch status {
+		case statusSkip:
+			fmt.Fprintf(e.out, "%-9s %s\n", status, name)
+		case statusCreate, statusModify:
+			writes = append(writes, pendingWrite{
+				file:     file,
+				existing: existing,
+				backup:   status == statusModify && policy == ConflictBackup,
+			})
+		}
+	}
+
+	for _, w := range writes {
+		// 只创建模板中的子目录，输出目录本身由调用方负责
+		if dir := filepath.Dir(w.file.path); dir != filepath.Clean(outputDir) {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("create output directory: %w", err)
+			}
+		}
+		if w.backup {
+			if err := backupFile(w.file.path, w.existing); err != nil {
+				return err
+			}
+		}
+		if err := os.WriteFile(w.file.path, w.file.content, 0644); err != nil {
+			return fmt.Errorf("create output file %s: %w", w.file.path, err)
+		}
+	}
+	return nil
+}
+
+// fileStatus 比较已有文件和生成内容，返回文件的变更状态
+func fileStatus(existing []byte, exists bool, content []byte) string {
+	switch {
+	case !exists:
+		return statusCreate
+	case bytes.Equal(existing, content):
+		return statusUnchanged
+	default:
+		return statusModify
+	}
+}
+
+// unifiedDiff 生成已有文件与生成内容之间的统一差异格式文本
+func unifiedDiff(name string, existing, content []byte, create bool) (string, error) {
+	diff := difflib.UnifiedDiff{
+		A:        splitLines(existing),
+		B:        splitLines(content),
+		FromFile: "a/" + name,
+		ToFile:   "b/" + name,
+		Context:  3,
+	}
+	if create {
+		diff.FromFile = "/dev/null"
+	}
+	return difflib.GetUnifiedDiffString(diff)
+}
+
+// splitLines 按行拆分文本并保留换行符，空文本没有任何行
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if last := lines[len(lines)-1]; last == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] = last + "\n"
+	}
+	return lines
+}
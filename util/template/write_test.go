@@ -0,0 +1,46 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStatus(t *testing.T) {
+	testCases := []struct {
+		name     string
+		existing string
+		exists   bool
+		content  string
+		expected string
+	}{
+		{"new file", "", false, "package model\n", statusCreate},
+		{"same content", "package model\n", true, "package model\n", statusUnchanged},
+		{"different content", "package model\n", true, "package dao\n", statusModify},
+		{"empty existing file", "", true, "package model\n", statusModify},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, fileStatus([]byte(tc.existing), tc.exists, []byte(tc.content)))
+		})
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{"empty", "", nil},
+		{"trailing newline", "a\nb\n", []string{"a\n", "b\n"}},
+		{"no trailing newline", "a\nb", []string{"a\n", "b\n"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, splitLines([]byte(tc.content)))
+		})
+	}
+}